@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"vexora-studio/internal/api"
+	"vexora-studio/internal/database"
+)
+
+const keysUsage = `usage: vexora keys [-db path] <command> [flags]
+
+  create -name n -scopes s[,s] [-projects p[,p]] [-expires 720h]
+                  issue a key; scopes are read, generate, approve, admin
+  list            list keys, revoked ones included
+  rotate [-grace 24h] <id>
+                  replace a key, keeping the old one valid for the grace period
+  revoke <id>     revoke a key
+
+A key's secret is printed once, by create and rotate. Only its hash is stored.`
+
+// runKeys implements `vexora keys ...` and returns the exit code
+func runKeys(args []string) int {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	dbFile := fs.String("db", "./data/vexora.db", "database file")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, keysUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cmd := flag.NewFlagSet("keys "+fs.Arg(0), flag.ContinueOnError)
+	cmd.Usage = fs.Usage
+	name := cmd.String("name", "", "key name")
+	scopes := cmd.String("scopes", "", "comma-separated scopes")
+	projects := cmd.String("projects", "", "comma-separated projects (default all)")
+	expires := cmd.Duration("expires", 0, "lifetime (default never expires)")
+	grace := cmd.Duration("grace", 0, "how long a rotated key stays valid")
+	if err := cmd.Parse(fs.Args()[1:]); err != nil {
+		return 2
+	}
+
+	// Opening runs pending migrations, so api_keys exists
+	if err := database.Init(*dbFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to open database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	switch fs.Arg(0) {
+	case "create":
+		if *name == "" {
+			fmt.Fprintln(os.Stderr, "❌ -name is required")
+			return 2
+		}
+		s, err := api.ParseScopes(*scopes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 2
+		}
+		key, secret, err := database.CreateAPIKey(*name, s, api.ParseProjects(*projects), *expires)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		printKey(key, secret)
+
+	case "list":
+		keys, err := database.ListAPIKeys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tSCOPES\tPROJECTS\tSTATUS\tLAST USED")
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%d\t%s\t%s…\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix,
+				strings.Join(k.Scopes, ","), orAll(k.Projects), keyStatus(k), k.LastUsedAt)
+		}
+		tw.Flush()
+
+	case "rotate", "revoke":
+		id, err := strconv.ParseInt(cmd.Arg(0), 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid key ID %q\n", cmd.Arg(0))
+			return 2
+		}
+		if fs.Arg(0) == "revoke" {
+			revoked, err := database.RevokeAPIKey(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				return 1
+			}
+			if !revoked {
+				fmt.Fprintf(os.Stderr, "❌ No active key #%d\n", id)
+				return 1
+			}
+			fmt.Printf("✅ Revoked key #%d\n", id)
+			return 0
+		}
+		key, secret, err := database.RotateAPIKey(id, *grace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ No active key #%d: %v\n", id, err)
+			return 1
+		}
+		printKey(key, secret)
+
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
+
+func printKey(k *database.APIKey, secret string) {
+	fmt.Printf("✅ Key #%d %q (%s, projects: %s)\n", k.ID, k.Name, strings.Join(k.Scopes, ","), orAll(k.Projects))
+	fmt.Println(secret)
+	fmt.Println("Store it now, it can't be shown again.")
+}
+
+func keyStatus(k database.APIKey) string {
+	switch {
+	case k.RevokedAt != "":
+		return "revoked"
+	case k.ExpiresAt != "":
+		if t, err := time.Parse(time.RFC3339, k.ExpiresAt); err == nil && t.Before(time.Now()) {
+			return "expired"
+		}
+		return "expires " + k.ExpiresAt
+	}
+	return "active"
+}
+
+func orAll(projects []string) string {
+	if len(projects) == 0 {
+		return "all"
+	}
+	return strings.Join(projects, ",")
+}
@@ -1,19 +1,35 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"time"
 
 	"vexora-studio/internal/api"
+	"vexora-studio/internal/approval"
 	"vexora-studio/internal/dashboard"
 	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/middleware"
+	"vexora-studio/internal/webhooks"
+	"vexora-studio/internal/worker"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "notify" {
+		os.Exit(runNotify(os.Args[2:]))
+	}
+
 	// 1. Setup Data Directory
 	if err := os.MkdirAll("data", 0755); err != nil {
 		log.Fatalf("❌ Failed to create data directory: %v", err)
@@ -23,33 +39,108 @@ func main() {
 	if err := database.Init("./data/vexora.db"); err != nil {
 		log.Fatalf("❌ Failed to initialize database: %v", err)
 	}
+	llm.SetStore(api.LLMStore{})
+
+	// 3. Start Queue Workers
+	worker.Start(context.Background(), worker.Count())
+	webhooks.Start(context.Background())
+	startNotifier(context.Background())
 
-	// 3. Setup Router
+	// 4. Setup Router
 	mux := http.NewServeMux()
 
+	// API key scopes (see internal/middleware/apikey.go)
+	read := func(h http.HandlerFunc) http.HandlerFunc { return middleware.RequireScope(database.ScopeRead, h) }
+	generate := func(h http.HandlerFunc) http.HandlerFunc { return middleware.RequireScope(database.ScopeGenerate, h) }
+	admin := func(h http.HandlerFunc) http.HandlerFunc { return middleware.RequireScope(database.ScopeAdmin, h) }
+	if !middleware.AuthEnabled() {
+		log.Println("⚠️ VEXORA_AUTH=off: the API accepts requests without an API key")
+	}
+
 	// Static Frontend (for testing)
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "templates/index.html")
 	})
 
-	// API Endpoints
-	mux.HandleFunc("POST /instagram", api.HandleCreateInstagramFeed)
-	mux.HandleFunc("GET /instagram", api.HandleGetTodaysInstagramFeeds)
-	mux.HandleFunc("GET /instagram/{identifier}", api.HandleGetInstagramFeeds)
-
-	mux.HandleFunc("POST /twitter", api.HandleCreateTwitterFeed)
-	mux.HandleFunc("GET /twitter", api.HandleGetTodaysTwitterFeeds)
-	mux.HandleFunc("GET /twitter/{identifier}", api.HandleGetTwitterFeeds)
-
-	mux.HandleFunc("POST /linkedin", api.HandleCreateLinkedinFeed)
-	mux.HandleFunc("GET /linkedin", api.HandleGetTodaysLinkedinFeeds)
-	mux.HandleFunc("GET /linkedin/{identifier}", api.HandleGetLinkedinFeeds)
-
-	mux.HandleFunc("POST /newsletter", api.HandleCreateNewsletterFeed)
-	mux.HandleFunc("GET /newsletter", api.HandleGetTodaysNewsletterFeeds)
-	mux.HandleFunc("GET /newsletter/{identifier}", api.HandleGetNewsletterFeeds)
+	// API Endpoints (one set per registered platform)
+	for _, platform := range llm.PlatformNames() {
+		api.RegisterPlatformRoutes(mux, platform)
+	}
 
-	// 4. Start Server
+	mux.HandleFunc("GET /llm/health", read(api.HandleGetLLMHealth))
+	mux.HandleFunc("GET /usage", read(api.HandleGetUsage))
+
+	// Multi-platform fan-out
+	mux.HandleFunc("POST /generate", generate(api.HandleGenerate))
+	mux.HandleFunc("GET /campaigns/{id}", read(api.HandleGetCampaign))
+
+	// Prompt Templates
+	mux.HandleFunc("GET /prompts", read(api.HandleListPrompts))
+	mux.HandleFunc("GET /prompts/{name}", read(api.HandleGetPrompt))
+	mux.HandleFunc("POST /prompts/{name}", admin(api.HandleSavePrompt))
+	mux.HandleFunc("DELETE /prompts/{name}", admin(api.HandleDeletePrompt))
+	mux.HandleFunc("GET /prompts/{name}/versions", read(api.HandleListPromptVersions))
+	mux.HandleFunc("GET /prompts/{name}/versions/{version}", read(api.HandleGetPromptVersion))
+
+	// Projects
+	mux.HandleFunc("GET /projects", read(api.HandleListProjects))
+	mux.HandleFunc("POST /projects", admin(api.HandleCreateProject))
+	mux.HandleFunc("GET /projects/{project}", read(api.HandleGetProject))
+	mux.HandleFunc("PUT /projects/{project}", admin(api.HandleUpdateProject))
+	mux.HandleFunc("DELETE /projects/{project}", admin(api.HandleDeleteProject))
+	mux.HandleFunc("GET /projects/{project}/feeds", read(api.HandleGetProjectFeeds))
+
+	// Voice Profiles
+	mux.HandleFunc("GET /voices", read(api.HandleListVoices))
+	mux.HandleFunc("POST /voices", admin(api.HandleCreateVoice))
+	mux.HandleFunc("GET /voices/{name}", read(api.HandleGetVoice))
+	mux.HandleFunc("PUT /voices/{name}", admin(api.HandleUpdateVoice))
+	mux.HandleFunc("DELETE /voices/{name}", admin(api.HandleDeleteVoice))
+	mux.HandleFunc("GET /projects/{project}/voice", read(api.HandleGetProjectVoice))
+	mux.HandleFunc("PUT /projects/{project}/voice", admin(api.HandleSetProjectVoice))
+
+	// Generation Budgets
+	mux.HandleFunc("GET /budgets", read(api.HandleListBudgets))
+	mux.HandleFunc("PUT /budgets/global/{period}", admin(api.HandleSetBudget))
+	mux.HandleFunc("DELETE /budgets/global/{period}", admin(api.HandleDeleteBudget))
+	mux.HandleFunc("GET /projects/{project}/budgets", read(api.HandleGetProjectBudgets))
+	mux.HandleFunc("PUT /projects/{project}/budgets/{period}", admin(api.HandleSetBudget))
+	mux.HandleFunc("DELETE /projects/{project}/budgets/{period}", admin(api.HandleDeleteBudget))
+
+	// Job Queue
+	mux.HandleFunc("POST /jobs", generate(api.HandleCreateJob))
+	mux.HandleFunc("GET /jobs/{id}", read(api.HandleGetJob))
+	mux.HandleFunc("POST /jobs/{id}/retry", generate(api.HandleRetryJob))
+
+	// Approval Workflow (signed links, see internal/approval, or a key with the approve scope)
+	mux.HandleFunc("GET /jobs/{id}/approve", middleware.OptionalKey(api.HandleApprovalPage(approval.ActionApprove)))
+	mux.HandleFunc("GET /jobs/{id}/reject", middleware.OptionalKey(api.HandleApprovalPage(approval.ActionReject)))
+	mux.HandleFunc("POST /jobs/{id}/approve", middleware.OptionalKey(api.HandleApproveJob))
+	mux.HandleFunc("POST /jobs/{id}/reject", middleware.OptionalKey(api.HandleRejectJob))
+	mux.HandleFunc("POST /jobs/{id}/edit", middleware.OptionalKey(api.HandleEditJob))
+	mux.HandleFunc("GET /jobs/{id}/audit", read(api.HandleGetJobAudit))
+
+	// Git forge webhooks (signed per source, see internal/hooks)
+	mux.HandleFunc("POST /hooks/{source}", api.HandleHook)
+
+	// Outbound Webhooks (see internal/webhooks)
+	mux.HandleFunc("GET /webhooks", admin(api.HandleListWebhooks))
+	mux.HandleFunc("POST /webhooks", admin(api.HandleCreateWebhook))
+	mux.HandleFunc("GET /webhooks/{id}", admin(api.HandleGetWebhook))
+	mux.HandleFunc("PUT /webhooks/{id}", admin(api.HandleUpdateWebhook))
+	mux.HandleFunc("DELETE /webhooks/{id}", admin(api.HandleDeleteWebhook))
+	mux.HandleFunc("POST /webhooks/{id}/rotate", admin(api.HandleRotateWebhookSecret))
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", admin(api.HandleListWebhookDeliveries))
+	mux.HandleFunc("GET /webhooks/{id}/deliveries/{delivery}", admin(api.HandleGetWebhookDelivery))
+	mux.HandleFunc("POST /webhooks/{id}/deliveries/{delivery}/redeliver", admin(api.HandleRedeliverWebhook))
+
+	// API Keys
+	mux.HandleFunc("GET /keys", admin(api.HandleListKeys))
+	mux.HandleFunc("POST /keys", admin(api.HandleCreateKey))
+	mux.HandleFunc("POST /keys/{id}/rotate", admin(api.HandleRotateKey))
+	mux.HandleFunc("DELETE /keys/{id}", admin(api.HandleRevokeKey))
+
+	// 5. Start Server
 	port := ":8081"
 	log.Printf("📸 Vexora Studio listening on %s", port)
 	log.Println("🖥️  Dashboard available at http://localhost:8081/dashboard")
@@ -63,9 +154,6 @@ func main() {
 		WriteTimeout: 300 * time.Second,
 	}
 
-	log.Println("Sending the mail")
-	// smtp.Mail()
-
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"vexora-studio/internal/database"
+)
+
+const migrateUsage = `usage: vexora migrate [-db path] status|up|down [steps]
+
+  status        list migrations and whether they are applied
+  up            apply every pending migration
+  down [steps]  roll back the last applied migration(s), default 1
+
+The database is backed up next to itself before up/down change anything.`
+
+// runMigrate implements `vexora migrate ...` and returns the exit code
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dbFile := fs.String("db", "./data/vexora.db", "database file")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := database.Open(*dbFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to open database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	switch fs.Arg(0) {
+	case "status":
+		states, err := database.MigrationStatus()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range states {
+			status := "pending"
+			switch {
+			case s.Unknown:
+				status = "unknown to this build"
+			case s.Mismatch:
+				status = "checksum mismatch"
+			case s.Applied:
+				status = "applied"
+			}
+			fmt.Fprintf(tw, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, status, s.AppliedAt)
+		}
+		tw.Flush()
+
+	case "up":
+		n, err := database.MigrateUp()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Applied %d migrations\n", n)
+
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "❌ Invalid step count %q\n", fs.Arg(1))
+				return 2
+			}
+			steps = n
+		}
+		n, err := database.MigrateDown(steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Rolled back %d migrations\n", n)
+
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
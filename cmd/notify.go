@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"vexora-studio/internal/approval"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/notify"
+)
+
+const notifyUsage = `usage: vexora notify test [address ...]
+
+Sends a sample approval email through the configured backend, to the
+given addresses or else the reviewers. Configuration comes from
+NOTIFY_CONFIG (a JSON file) and NOTIFY_*, SMTP_* and GRAPH_* variables.
+For a local sink such as Mailpit: NOTIFY_BACKEND=smtp SMTP_HOST=localhost
+SMTP_PORT=1025 SMTP_TLS=none SMTP_FROM=vexora@localhost`
+
+// startNotifier emails reviewers about jobs waiting for approval, if a
+// backend is configured
+func startNotifier(ctx context.Context) {
+	cfg, err := notify.LoadConfig()
+	if err != nil {
+		log.Printf("⚠️ Notifications disabled: %v", err)
+		return
+	}
+	n, err := notify.New(cfg)
+	if err != nil {
+		log.Printf("⚠️ Notifications disabled: %v", err)
+		return
+	}
+	if n == nil {
+		return
+	}
+	if len(cfg.Reviewers) == 0 {
+		log.Println("⚠️ Notifications disabled: no reviewers configured (NOTIFY_REVIEWERS)")
+		return
+	}
+	notify.StartReviewers(ctx, n, cfg.Reviewers)
+}
+
+// runNotify implements `vexora notify ...` and returns the exit code
+func runNotify(args []string) int {
+	fs := flag.NewFlagSet("notify", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, notifyUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.Arg(0) != "test" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := notify.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	n, err := notify.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if n == nil {
+		fmt.Fprintln(os.Stderr, "❌ No backend configured (NOTIFY_BACKEND=smtp or graph)")
+		return 1
+	}
+	to := fs.Args()[1:]
+	if len(to) == 0 {
+		to = cfg.Reviewers
+	}
+	if len(to) == 0 {
+		fmt.Fprintln(os.Stderr, "❌ No recipients: pass addresses or set NOTIFY_REVIEWERS")
+		return 2
+	}
+
+	token, _ := approval.NewToken()
+	job := database.QueueItem{
+		ProjectName:       "vexora",
+		FeedType:          "linkedin",
+		Status:            database.StatusWaitingApproval,
+		GeneratedContent:  "This is a sample draft. Approving it from this email does nothing.",
+		ApprovalToken:     token,
+		ApprovalExpiresAt: time.Now().UTC().Add(approval.TTL()).Format(database.SQLiteTime),
+	}
+	msg, err := notify.ApprovalMessage(job, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to render message: %v\n", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := n.Send(ctx, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("📧 Sent %q to %d recipients\n", msg.Subject, len(to))
+	return 0
+}
@@ -27,7 +27,8 @@ func main() {
 	}
 	fmt.Printf("Total entries: %d\n", count)
 
-	rows, err = db.Query("SELECT project_name FROM journal_entries")
+	rows, err = db.Query(`SELECT p.slug, p.name, p.archived, COUNT(c.id) FROM projects p
+		LEFT JOIN contents c ON c.project_id = p.id GROUP BY p.id ORDER BY p.slug`)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -35,8 +36,14 @@ func main() {
 
 	fmt.Println("Projects:")
 	for rows.Next() {
-		var name string
-		rows.Scan(&name)
-		fmt.Println("- " + name)
+		var slug, name string
+		var archived bool
+		var feeds int
+		rows.Scan(&slug, &name, &archived, &feeds)
+		line := fmt.Sprintf("- %s (%s): %d feeds", slug, name, feeds)
+		if archived {
+			line += " [archived]"
+		}
+		fmt.Println(line)
 	}
 }
@@ -2,10 +2,7 @@ module vexora-studio // <--- THIS LINE IS KEY
 
 go 1.24.0 // or whatever your version is
 
-require github.com/mattn/go-sqlite3 v1.14.32
-
 require (
-	golang.org/x/oauth2 v0.34.0 // indirect
-	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
-	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df // indirect
+	github.com/mattn/go-sqlite3 v1.14.32
+	golang.org/x/oauth2 v0.34.0
 )
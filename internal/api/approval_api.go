@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"vexora-studio/internal/approval"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/middleware"
+	"vexora-studio/internal/webhooks"
+)
+
+// HandleApproveJob moves a WAITING_APPROVAL job to APPROVED.
+// Requires the exp/sig pair from a signed approve link.
+func HandleApproveJob(w http.ResponseWriter, r *http.Request) {
+	job, token, ok := verifyApproval(w, r, approval.ActionApprove)
+	if !ok {
+		return
+	}
+
+	if err := database.ApproveJob(job.ID, token); err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	syncContent(job.ID, database.ContentApproved)
+	audit(r, job.ID, approval.ActionApprove, "")
+	log.Printf("👍 Job #%d approved by %s", job.ID, actor(r))
+	webhooks.EmitJob(webhooks.ContentApproved, job.ID, map[string]any{"approved_by": actor(r)})
+	writeJob(w, job.ID)
+}
+
+// HandleRejectJob sends the job back into the queue; "reason" is kept in the audit log
+func HandleRejectJob(w http.ResponseWriter, r *http.Request) {
+	job, token, ok := verifyApproval(w, r, approval.ActionReject)
+	if !ok {
+		return
+	}
+
+	if err := database.RejectJob(job.ID, token); err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	syncContent(job.ID, database.ContentRejected)
+	audit(r, job.ID, approval.ActionReject, r.FormValue("reason"))
+	log.Printf("👎 Job #%d rejected by %s, re-queued", job.ID, actor(r))
+	writeJob(w, job.ID)
+}
+
+// HandleEditJob saves the reviewer's version of the subject/content
+func HandleEditJob(w http.ResponseWriter, r *http.Request) {
+	job, token, ok := verifyApproval(w, r, approval.ActionEdit)
+	if !ok {
+		return
+	}
+
+	content := r.FormValue("content")
+	if content == "" {
+		http.Error(w, "Content is required", 400)
+		return
+	}
+	subject := job.GeneratedSubject
+	if r.Form.Has("subject") {
+		subject = r.FormValue("subject")
+	}
+
+	if err := database.EditJobContent(job.ID, token, subject, content); err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+	reviseContent(r, job.ID)
+	audit(r, job.ID, approval.ActionEdit, job.GeneratedContent)
+	writeJob(w, job.ID)
+}
+
+// HandleGetJobAudit lists the approval history of a job
+func HandleGetJobAudit(w http.ResponseWriter, r *http.Request) {
+	job, ok := loadJob(w, r)
+	if !ok {
+		return
+	}
+	records, err := database.GetAuditByJob(job.ID)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Error", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8"><title>Vexora Studio</title></head>
+<body style="font-family: sans-serif; max-width: 640px; margin: 40px auto;">
+	<h2>{{.Title}} job #{{.Job.ID}}?</h2>
+	<p><strong>{{.Job.ProjectName}}</strong> · {{.Job.FeedType}}</p>
+	<pre style="white-space: pre-wrap; background: #f3f4f6; padding: 1em;">{{.Job.GeneratedContent}}</pre>
+	<form method="POST" action="{{.Action}}">
+		{{if eq .Title "Reject"}}<p><input name="reason" placeholder="Reason (optional)" style="width: 100%"></p>{{end}}
+		<button type="submit">{{.Title}}</button>
+	</form>
+</body>
+</html>`))
+
+// HandleApprovalPage answers GET on a signed link (e.g. from an email) with a
+// confirmation form, so link scanners can't approve anything by prefetching.
+func HandleApprovalPage(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, _, ok := verifyApproval(w, r, action)
+		if !ok {
+			return
+		}
+		title := "Approve"
+		if action == approval.ActionReject {
+			title = "Reject"
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		confirmPage.Execute(w, map[string]any{
+			"Title":  title,
+			"Job":    job,
+			"Action": r.URL.RequestURI(),
+		})
+	}
+}
+
+// verifyApproval loads the job and checks the signed exp/sig parameters
+// against the job's approval token. An API key with the approve scope
+// stands in for the signature.
+func verifyApproval(w http.ResponseWriter, r *http.Request, action string) (*database.QueueItem, string, bool) {
+	job, ok := loadJob(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	if job.Status != database.StatusWaitingApproval {
+		http.Error(w, "Job is not awaiting approval", http.StatusConflict)
+		return nil, "", false
+	}
+
+	token, err := database.GetToken(strconv.FormatInt(job.ID, 10))
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Error", 500)
+		return nil, "", false
+	}
+
+	if key := middleware.Caller(r.Context()); key != nil && key.HasScope(database.ScopeApprove) {
+		return job, token, true
+	}
+	exp, _ := strconv.ParseInt(r.FormValue("exp"), 10, 64)
+	if err := approval.Verify(token, job.ID, action, exp, r.FormValue("sig"), time.Now()); err != nil {
+		log.Printf("⛔ Security Alert: %s on job #%d: %v", action, job.ID, err)
+		if errors.Is(err, approval.ErrExpired) {
+			http.Error(w, "Approval link expired", http.StatusGone)
+			return nil, "", false
+		}
+		http.Error(w, "Unauthorized", 401)
+		return nil, "", false
+	}
+	return job, token, true
+}
+
+func writeApprovalError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrApprovalConflict) {
+		http.Error(w, "Job is not awaiting approval or the link has expired", http.StatusConflict)
+		return
+	}
+	log.Printf("❌ DB Error: %v", err)
+	http.Error(w, "Database Update Failed", 500)
+}
+
+func writeJob(w http.ResponseWriter, id int64) {
+	job, err := database.GetEntry(id)
+	if err != nil {
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// syncContent mirrors a review decision onto the job's contents row
+func syncContent(jobID int64, status string) {
+	job, err := database.GetEntry(jobID)
+	if err != nil || job.ContentID == 0 {
+		return
+	}
+	if err := database.Contents().UpdateStatus(job.ContentID, status); err != nil {
+		log.Printf("❌ Content Update Failed for job #%d: %v", jobID, err)
+	}
+}
+
+// reviseContent records a reviewer's edit in the feed's revision history
+func reviseContent(r *http.Request, jobID int64) {
+	job, err := database.GetEntry(jobID)
+	if err != nil || job.ContentID == 0 {
+		return
+	}
+	output := job.GeneratedContent
+	if job.FeedType == llm.TypeNewsletter {
+		output = reviewedNewsletter(job)
+	}
+	if _, err := database.Contents().Revise(job.ContentID, output, database.RevisionEdited, actor(r), 0); err != nil {
+		log.Printf("❌ Revision Insert Failed for job #%d: %v", jobID, err)
+	}
+}
+
+// reviewedNewsletter rebuilds the newsletter JSON with the reviewed subject and body
+func reviewedNewsletter(job *database.QueueItem) string {
+	nl := map[string]any{}
+	if content, err := database.Contents().GetByID(job.FeedType, job.ContentID); err == nil {
+		json.Unmarshal([]byte(content.Output), &nl)
+	}
+	nl["subject_line"] = job.GeneratedSubject
+	nl["body"] = job.GeneratedContent
+	out, _ := json.Marshal(nl)
+	return string(out)
+}
+
+func audit(r *http.Request, jobID int64, action, detail string) {
+	if err := database.InsertAudit(jobID, action, actor(r), detail, r.RemoteAddr); err != nil {
+		log.Printf("❌ Audit Insert Failed: %v", err)
+	}
+}
+
+// actor names whoever made the request: the API key's name, otherwise the
+// signed link it came through. Client-supplied names aren't trusted.
+func actor(r *http.Request) string {
+	if key := middleware.Caller(r.Context()); key != nil {
+		return "key:" + key.Name
+	}
+	if r.FormValue("sig") != "" {
+		return "signed-link:job-" + r.PathValue("id")
+	}
+	return "anonymous"
+}
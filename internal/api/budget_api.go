@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"vexora-studio/internal/database"
+)
+
+// Budgets cap LLM usage per UTC day or month, globally
+// (/budgets/global/{period}) or per project (/projects/{project}/budgets/{period}).
+// Once one is used up, generations fail with 429 or, with action=fallback,
+// run on the local Ollama provider instead.
+
+// HandleListBudgets returns every budget with the usage of its current period
+func HandleListBudgets(w http.ResponseWriter, r *http.Request) {
+	budgets, err := database.ListBudgets()
+	budgets = slices.DeleteFunc(budgets, func(b database.Budget) bool {
+		return b.ProjectName != "" && !projectAllowed(r, b.ProjectName)
+	})
+	writeBudgetStates(w, budgets, err)
+}
+
+// HandleGetProjectBudgets returns the budgets a project counts against,
+// the global ones included
+func HandleGetProjectBudgets(w http.ResponseWriter, r *http.Request) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return
+	}
+	budgets, err := database.ProjectBudgets(database.Slugify(r.PathValue("project")))
+	writeBudgetStates(w, budgets, err)
+}
+
+// HandleSetBudget creates or replaces a budget from the max_tokens,
+// max_cost_usd and action (reject or fallback, default reject) fields.
+// The project path value is empty on the global route.
+func HandleSetBudget(w http.ResponseWriter, r *http.Request) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return
+	}
+	b := &database.Budget{
+		ProjectName: database.Slugify(r.PathValue("project")),
+		Period:      r.PathValue("period"),
+		Action:      r.FormValue("action"),
+	}
+	if b.Period != database.BudgetDaily && b.Period != database.BudgetMonthly {
+		http.Error(w, "Period must be daily or monthly", 400)
+		return
+	}
+	switch b.Action {
+	case "":
+		b.Action = database.BudgetReject
+	case database.BudgetReject, database.BudgetFallback:
+	default:
+		http.Error(w, "action must be reject or fallback", 400)
+		return
+	}
+	if v := r.FormValue("max_tokens"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "max_tokens must be a non-negative integer", 400)
+			return
+		}
+		b.MaxTokens = n
+	}
+	if v := r.FormValue("max_cost_usd"); v != "" {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil || c < 0 {
+			http.Error(w, "max_cost_usd must be a non-negative number", 400)
+			return
+		}
+		b.MaxCostUSD = c
+	}
+	if b.MaxTokens == 0 && b.MaxCostUSD == 0 {
+		http.Error(w, "Set max_tokens, max_cost_usd or both", 400)
+		return
+	}
+
+	if err := database.SetBudget(b); err != nil {
+		log.Printf("❌ Budget Update Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	state, err := b.State(time.Now())
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+func HandleDeleteBudget(w http.ResponseWriter, r *http.Request) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return
+	}
+	deleted, err := database.DeleteBudget(database.Slugify(r.PathValue("project")), r.PathValue("period"))
+	if err != nil {
+		log.Printf("❌ Budget Delete Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Budget not found", 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeBudgetStates(w http.ResponseWriter, budgets []database.Budget, err error) {
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	states := make([]database.BudgetState, 0, len(budgets))
+	for _, b := range budgets {
+		state, err := b.State(time.Now())
+		if err != nil {
+			log.Printf("❌ DB Error: %v", err)
+			http.Error(w, "Database Retrieval Failed", 500)
+			return
+		}
+		states = append(states, state)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(states)
+}
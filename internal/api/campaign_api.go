@@ -0,0 +1,230 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+)
+
+// campaignResult is the outcome for one platform of POST /generate
+type campaignResult struct {
+	Platform   string                `json:"platform"`
+	Status     string                `json:"status"` // ok or failed
+	ContentID  int64                 `json:"content_id,omitempty"`
+	Provider   string                `json:"provider,omitempty"`
+	Model      string                `json:"model,omitempty"`
+	Feed       any                   `json:"feed,omitempty"`
+	Validation *llm.ValidationReport `json:"validation,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// HandleGenerate fans one set of notes out to several platforms at once and
+// stores the feeds as a campaign. platforms is comma-separated (default: the
+// project's default platforms, or all);
+// every other field is the same as for POST /{platform}, with thread
+// settings applying to twitter only.
+//
+// Responds 201 when at least one platform succeeded, with each platform's
+// result in request order; "status" says whether the campaign is complete
+// or partial. If every platform failed it responds 502.
+func HandleGenerate(w http.ResponseWriter, r *http.Request) {
+	rawContent := r.FormValue("raw_content")
+
+	if rawContent == "" {
+		http.Error(w, "Raw content is required", 400)
+		return
+	}
+	project, ok := generationProject(w, r, r.FormValue("project_name"))
+	if !ok {
+		return
+	}
+	requested := r.FormValue("platforms")
+	if requested == "" && project != nil {
+		requested = strings.Join(project.DefaultPlatforms, ",")
+	}
+	platforms, err := campaignPlatforms(requested)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	opts, err := generationOptions(r, llm.Options{})
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	in := generationInput(r, rawContent, project)
+	thread, err := threadOptions(r, llm.TypeTwitter, nil)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	// a bad profile name would fail every platform the same way
+	if in.VoiceProfile != "" {
+		if _, err := database.GetVoice(in.VoiceProfile); err != nil {
+			http.Error(w, "Unknown voice profile", 400)
+			return
+		}
+	}
+	// and so would a used-up budget
+	if err := llm.CheckBudget(in.Project); err != nil {
+		writeGenerationError(w, err)
+		return
+	}
+
+	campaign := &database.Campaign{ProjectName: in.Project, RawNotes: rawContent, Platforms: platforms}
+	if err := database.InsertCampaign(campaign); err != nil {
+		log.Printf("❌ Campaign Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+
+	results := make([]campaignResult, len(platforms))
+	sem := make(chan struct{}, generateConcurrency())
+	var wg sync.WaitGroup
+	for i, platform := range platforms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			pin := in
+			if platform == llm.TypeTwitter {
+				pin.Thread = thread
+			}
+			results[i] = generateForCampaign(campaign, platform, pin, opts)
+		}()
+	}
+	wg.Wait()
+
+	campaign.Failures = map[string]string{}
+	for _, res := range results {
+		if res.Status != "ok" {
+			campaign.Failures[res.Platform] = res.Error
+		}
+	}
+	switch len(campaign.Failures) {
+	case 0:
+		campaign.Status = database.CampaignCompleted
+	case len(platforms):
+		campaign.Status = database.CampaignFailed
+	default:
+		campaign.Status = database.CampaignPartial
+	}
+	if err := database.FinishCampaign(campaign); err != nil {
+		log.Printf("❌ Campaign #%d Update Failed: %v", campaign.ID, err)
+	}
+	log.Printf("📣 Campaign #%d %s (%d/%d platforms)", campaign.ID, campaign.Status, len(platforms)-len(campaign.Failures), len(platforms))
+
+	code := http.StatusCreated
+	if campaign.Status == database.CampaignFailed {
+		code = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/campaigns/"+strconv.FormatInt(campaign.ID, 10))
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"campaign": campaign, "results": results})
+}
+
+// generateForCampaign generates and stores one platform's feed
+func generateForCampaign(campaign *database.Campaign, platform string, in llm.Input, opts llm.Options) campaignResult {
+	res := campaignResult{Platform: platform, Status: "failed"}
+
+	data, err := llm.GenerateContent(platform, in, opts)
+	if err != nil {
+		log.Printf("❌ Campaign #%d: %s Generation Failed: %v", campaign.ID, platform, err)
+		res.Error = generationErrorMessage(err)
+		return res
+	}
+
+	content := newContent(platform, campaign.ProjectName, campaign.RawNotes, data)
+	content.CampaignID = campaign.ID
+	if err := database.Contents().Insert(content); err != nil {
+		log.Printf("❌ Campaign #%d: %s DB Insert Failed: %v", campaign.ID, platform, err)
+		res.Error = "Database Insertion Failed"
+		return res
+	}
+	emitGenerated(content)
+
+	res.Status = "ok"
+	res.ContentID = content.ID
+	res.Provider, res.Model = data.Provider, data.Model
+	res.Feed = feedValue(*content)
+	res.Validation = data.Validation
+	return res
+}
+
+// HandleGetCampaign returns a campaign with all of its feeds
+func HandleGetCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", 400)
+		return
+	}
+	campaign, err := database.GetCampaign(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Campaign not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	if !checkProject(w, r, campaign.ProjectName) {
+		return
+	}
+	contents, err := database.Contents().ListByCampaign(id)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	if contents == nil {
+		contents = []database.Content{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"campaign": campaign, "contents": contents})
+}
+
+// campaignPlatforms parses the comma-separated platforms field, in order
+// and without duplicates. Empty means every registered platform.
+func campaignPlatforms(field string) ([]string, error) {
+	if strings.TrimSpace(field) == "" {
+		return llm.PlatformNames(), nil
+	}
+	var platforms []string
+	for _, p := range strings.Split(field, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || slices.Contains(platforms, p) {
+			continue
+		}
+		if !llm.IsFeedType(p) {
+			return nil, errors.New("unsupported platform: " + p)
+		}
+		platforms = append(platforms, p)
+	}
+	if len(platforms) == 0 {
+		return nil, errors.New("at least one platform is required")
+	}
+	return platforms, nil
+}
+
+// generateConcurrency reads GENERATE_CONCURRENCY, how many platforms of one
+// campaign are generated at the same time (default 4)
+func generateConcurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("GENERATE_CONCURRENCY")); err == nil && n > 0 {
+		return n
+	}
+	return 4
+}
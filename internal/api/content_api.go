@@ -0,0 +1,406 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/middleware"
+)
+
+// RegisterPlatformRoutes wires the standard endpoints for a platform:
+//
+//	POST /{platform}                 generate and store
+//	POST /{platform}/stream          same, streamed as SSE
+//	POST /{platform}/{id}/regenerate new version from the stored raw notes
+//	GET  /{platform}                 today's feeds
+//	GET  /{platform}/{identifier}    one feed by ID, or all feeds of a project
+//
+//	GET  /{platform}/{id}/revisions                 text history
+//	GET  /{platform}/{id}/revisions/{rev}           one revision
+//	POST /{platform}/{id}/revisions/{rev}/restore   make an old revision current
+//	GET  /{platform}/{id}/diff?from=&to=&mode=      unified or word diff
+//
+// The generating POST endpoints accept optional model, temperature and
+// max_tokens fields, plus voice_profile, voice, audience and hashtags
+// (comma-separated) for the prompt templates. Twitter also takes
+// mode=thread (see threadOptions); threads are served as arrays of tweets.
+//
+// POST endpoints need the generate scope, GET endpoints the read scope.
+func RegisterPlatformRoutes(mux *http.ServeMux, platform string) {
+	read := func(h http.HandlerFunc) http.HandlerFunc { return middleware.RequireScope(database.ScopeRead, h) }
+	generate := func(h http.HandlerFunc) http.HandlerFunc { return middleware.RequireScope(database.ScopeGenerate, h) }
+
+	mux.HandleFunc("POST /"+platform, generate(HandleCreateFeed(platform)))
+	mux.HandleFunc("POST /"+platform+"/stream", generate(HandleStreamFeed(platform)))
+	mux.HandleFunc("POST /"+platform+"/{id}/regenerate", generate(HandleRegenerateFeed(platform)))
+	mux.HandleFunc("GET /"+platform+"/{id}/revisions", read(HandleListRevisions(platform)))
+	mux.HandleFunc("GET /"+platform+"/{id}/revisions/{rev}", read(HandleGetRevision(platform)))
+	mux.HandleFunc("POST /"+platform+"/{id}/revisions/{rev}/restore", generate(HandleRestoreRevision(platform)))
+	mux.HandleFunc("GET /"+platform+"/{id}/diff", read(HandleDiffRevisions(platform)))
+	mux.HandleFunc("GET /"+platform, read(HandleGetTodaysFeeds(platform)))
+	mux.HandleFunc("GET /"+platform+"/{identifier}", read(HandleGetFeeds(platform)))
+}
+
+func HandleCreateFeed(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawContent := r.FormValue("raw_content")
+
+		if rawContent == "" {
+			http.Error(w, "Raw content is required", 400)
+			return
+		}
+		opts, err := generationOptions(r, llm.Options{})
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		project, ok := generationProject(w, r, r.FormValue("project_name"))
+		if !ok {
+			return
+		}
+		in := generationInput(r, rawContent, project)
+		if in.Thread, err = threadOptions(r, platform, nil); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		data, err := llm.GenerateContent(platform, in, opts)
+		if err != nil {
+			log.Printf("❌ %s Generation Failed: %v", platform, err)
+			writeGenerationError(w, err)
+			return
+		}
+
+		content := newContent(platform, in.Project, rawContent, data)
+		if err := database.Contents().Insert(content); err != nil {
+			log.Printf("❌ %s DB Insert Failed: %v", platform, err)
+			http.Error(w, "Database Insertion Failed", 500)
+			return
+		}
+		emitGenerated(content)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Vexora-Provider", data.Provider)
+		w.Header().Set("X-Vexora-Content-Id", strconv.FormatInt(content.ID, 10))
+		setValidationHeader(w, data)
+		w.Write([]byte(data.Text))
+	}
+}
+
+// HandleRegenerateFeed re-runs generation from a feed's stored raw notes and
+// saves the result as a new version linked to it. The original parameters
+// are reused unless the request overrides them.
+func HandleRegenerateFeed(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parent, ok := loadContent(w, r, platform)
+		if !ok {
+			return
+		}
+		if parent.RawNotes == "" {
+			http.Error(w, "Feed has no stored raw notes to regenerate from", 409)
+			return
+		}
+
+		var base llm.Options
+		if len(parent.Params) > 0 {
+			json.Unmarshal(parent.Params, &base)
+		}
+		opts, err := generationOptions(r, base)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		var meta struct {
+			Thread *llm.Thread `json:"thread"`
+		}
+		if len(parent.Metadata) > 0 {
+			json.Unmarshal(parent.Metadata, &meta)
+		}
+		project, ok := generationProject(w, r, parent.ProjectName)
+		if !ok {
+			return
+		}
+		in := generationInput(r, parent.RawNotes, project)
+		if in.Thread, err = threadOptions(r, platform, meta.Thread); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		data, err := llm.GenerateContent(platform, in, opts)
+		if err != nil {
+			log.Printf("❌ %s Regeneration Failed: %v", platform, err)
+			writeGenerationError(w, err)
+			return
+		}
+
+		content := newContent(platform, in.Project, parent.RawNotes, data)
+		content.ParentID = parent.ID
+		content.CampaignID = parent.CampaignID
+		if err := database.Contents().Insert(content); err != nil {
+			log.Printf("❌ %s DB Insert Failed: %v", platform, err)
+			http.Error(w, "Database Insertion Failed", 500)
+			return
+		}
+		emitGenerated(content)
+		log.Printf("🔁 Regenerated %s #%d as #%d (v%d)", platform, parent.ID, content.ID, content.Version)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Vexora-Provider", data.Provider)
+		w.Header().Set("Location", fmt.Sprintf("/%s/%d", platform, content.ID))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(content)
+	}
+}
+
+func HandleGetTodaysFeeds(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contents, err := database.Contents().ListToday(platform)
+		if err != nil {
+			log.Printf("❌ DB Error: %v", err)
+			http.Error(w, "Database Error", 500)
+			return
+		}
+		contents = slices.DeleteFunc(contents, func(c database.Content) bool { return !projectAllowed(r, c.ProjectName) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(outputs(contents))
+	}
+}
+
+func HandleGetFeeds(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identifier := r.PathValue("identifier")
+
+		// Try to fetch by ID first if it's numeric
+		if id, err := strconv.ParseInt(identifier, 10, 64); err == nil {
+			content, err := database.Contents().GetByID(platform, id)
+			if err == nil {
+				if !checkProject(w, r, content.ProjectName) {
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]any{"feed": feedValue(*content)})
+				return
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("❌ DB Error: %v", err)
+				http.Error(w, "Database Retrieval Failed", 500)
+				return
+			}
+		}
+
+		// Otherwise fetch by project name
+		if !checkProject(w, r, identifier) {
+			return
+		}
+		contents, err := database.Contents().ListByProject(platform, identifier)
+		if err != nil {
+			http.Error(w, "Database Retrieval Failed", 500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(outputs(contents)); err != nil {
+			http.Error(w, "JSON Encoding Failed", 500)
+		}
+	}
+}
+
+// newContent maps a generation result onto a contents row
+func newContent(platform, projectName, rawNotes string, res llm.Result) *database.Content {
+	c := &database.Content{
+		Platform:    platform,
+		ProjectName: projectName,
+		RawNotes:    rawNotes,
+		Output:      res.Text,
+		Provider:    res.Provider,
+		Model:       res.Model,
+		LatencyMS:   res.Latency.Milliseconds(),
+
+		PromptVersion: res.PromptVersion,
+
+		PromptTokens:     res.Usage.PromptTokens,
+		CompletionTokens: res.Usage.CompletionTokens,
+		CostUSD:          res.Usage.CostUSD,
+	}
+	if res.Metadata != nil {
+		if meta, err := json.Marshal(res.Metadata); err == nil {
+			c.Metadata = meta
+		}
+	}
+	c.Params, _ = json.Marshal(res.Params)
+	return c
+}
+
+// setValidationHeader summarises the validation report, e.g.
+// "passed" or "failed: max_length, hashtag_count". The full report is in
+// the stored feed's metadata.
+func setValidationHeader(w http.ResponseWriter, res llm.Result) {
+	v := res.Validation
+	if v == nil {
+		return
+	}
+	if v.Valid {
+		w.Header().Set("X-Vexora-Validation", "passed")
+		return
+	}
+	rules := make([]string, len(v.Violations))
+	for i, violation := range v.Violations {
+		rules[i] = violation.Rule
+	}
+	w.Header().Set("X-Vexora-Validation", "failed: "+strings.Join(rules, ", "))
+}
+
+// generationInput collects the voice profile and prompt template variables
+// sent with a request. The project, if any, supplies the hashtags when the
+// request has none.
+func generationInput(r *http.Request, notes string, project *database.Project) llm.Input {
+	in := llm.Input{
+		Notes:        notes,
+		VoiceProfile: r.FormValue("voice_profile"),
+		Voice:        r.FormValue("voice"),
+		Audience:     r.FormValue("audience"),
+	}
+	for _, tag := range strings.Split(r.FormValue("hashtags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			in.Hashtags = append(in.Hashtags, tag)
+		}
+	}
+	if project != nil {
+		in.Project = project.Slug
+		if len(in.Hashtags) == 0 {
+			in.Hashtags = project.Hashtags
+		}
+	}
+	return in
+}
+
+// generationOptions overlays the optional model, temperature, top_p, top_k,
+// max_tokens, stop, candidate_count and safety form fields on base. stop
+// and safety ("HARASSMENT=BLOCK_ONLY_HIGH") may be repeated.
+func generationOptions(r *http.Request, base llm.Options) (llm.Options, error) {
+	opts := base
+	if v := r.FormValue("model"); v != "" {
+		opts.Model = v
+	}
+	if v := r.FormValue("temperature"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 2 {
+			return opts, fmt.Errorf("temperature must be a number between 0 and 2")
+		}
+		opts.Temperature = &t
+	}
+	if v := r.FormValue("top_p"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p < 0 || p > 1 {
+			return opts, fmt.Errorf("top_p must be a number between 0 and 1")
+		}
+		opts.TopP = &p
+	}
+	if v := r.FormValue("top_k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("top_k must be a positive integer")
+		}
+		opts.TopK = n
+	}
+	if v := r.FormValue("max_tokens"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("max_tokens must be a positive integer")
+		}
+		opts.MaxTokens = n
+	}
+	if stop := r.Form["stop"]; len(stop) > 0 {
+		if len(stop) > 5 {
+			return opts, fmt.Errorf("at most 5 stop sequences are allowed")
+		}
+		opts.Stop = stop
+	}
+	if v := r.FormValue("candidate_count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 8 {
+			return opts, fmt.Errorf("candidate_count must be between 1 and 8")
+		}
+		opts.CandidateCount = n
+	}
+	if pairs := r.Form["safety"]; len(pairs) > 0 {
+		safety, err := llm.ParseSafetySettings(pairs)
+		if err != nil {
+			return opts, err
+		}
+		opts.Safety = safety
+	}
+	return opts, nil
+}
+
+// threadOptions reads the thread fields of a twitter request: mode=thread
+// (or single), thread_length, numbering (default true) and cta. Unset fields
+// keep base, the settings a regenerated feed was made with.
+func threadOptions(r *http.Request, platform string, base *llm.Thread) (*llm.Thread, error) {
+	switch r.FormValue("mode") {
+	case "":
+		if base == nil {
+			return nil, nil
+		}
+	case "single":
+		return nil, nil
+	case llm.FormatThread:
+		if platform != llm.TypeTwitter {
+			return nil, fmt.Errorf("thread mode is only available for twitter")
+		}
+	default:
+		return nil, fmt.Errorf("mode must be single or thread")
+	}
+
+	t := llm.Thread{Numbering: true}
+	if base != nil {
+		t = *base
+	}
+	if v := r.FormValue("thread_length"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 || n > llm.MaxThreadLength {
+			return nil, fmt.Errorf("thread_length must be between 2 and %d", llm.MaxThreadLength)
+		}
+		t.Length = n
+	}
+	if v := r.FormValue("numbering"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("numbering must be true or false")
+		}
+		t.Numbering = b
+	}
+	if v := r.FormValue("cta"); v != "" {
+		t.CTA = v
+	}
+	return &t, nil
+}
+
+// feedValue is a feed as served by the GET endpoints: the output string,
+// or the array of tweets for a thread
+func feedValue(c database.Content) any {
+	if llm.IsThread(c.Metadata) {
+		if tweets, err := llm.ThreadTweets(c.Output); err == nil {
+			return tweets
+		}
+	}
+	return c.Output
+}
+
+// outputs keeps the list endpoints' original shape: a plain array of feeds
+func outputs(contents []database.Content) []any {
+	feeds := make([]any, 0, len(contents))
+	for _, c := range contents {
+		feeds = append(feeds, feedValue(c))
+	}
+	return feeds
+}
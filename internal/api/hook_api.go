@@ -0,0 +1,146 @@
+package api
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/hooks"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/middleware"
+)
+
+// maxHookBody is the largest webhook payload accepted
+const maxHookBody = 5 << 20
+
+// HandleHook ingests a git forge webhook: POST /hooks/{source} with source
+// github, gitlab or gitea, signed with HOOKS_<SOURCE>_SECRET the way that
+// forge signs. Pushes to the default branch, published releases and merged
+// pull requests become raw notes and a job per default platform of the
+// project set in HOOKS_<SOURCE>_PROJECT, else the one whose repo_url
+// matches the signed repository. The project is never taken from the
+// unsigned URL.
+// Source vexora takes notes from CI, signed with a timestamp like
+// middleware.Sign does. Replays are refused: of any source by delivery ID
+// and by middleware.Signature.ReplayKey, which for forges that sign no
+// timestamp is the body hash.
+//
+// Anything valid answers 202 so the forge doesn't retry; "status" says
+// whether jobs were queued, or why not.
+func HandleHook(w http.ResponseWriter, r *http.Request) {
+	src, ok := hooks.GetSource(r.PathValue("source"))
+	if !ok {
+		http.Error(w, "Unknown hook source", 404)
+		return
+	}
+	secrets := src.Secrets()
+	if len(secrets) == 0 {
+		http.Error(w, "Hook source not configured", 503)
+		return
+	}
+
+	if r.URL.Query().Has("project") {
+		http.Error(w, "?project= is not accepted, the project comes from the hook's configuration or signed payload", 400)
+		return
+	}
+
+	body, ok := middleware.CheckSignature(w, r, middleware.SignatureConfig{
+		Signature: src.Signature,
+		Secrets:   secrets,
+		MaxBody:   maxHookBody,
+	})
+	if !ok {
+		return
+	}
+
+	d := &database.HookDelivery{
+		Source:     src.Name,
+		DeliveryID: r.Header.Get(src.DeliveryID),
+		Digest:     src.Signature.ReplayKey(r.Header, body),
+	}
+	ev, err := src.Parse(r, body)
+	if errors.Is(err, hooks.ErrIgnored) {
+		d.Status, d.Detail = database.HookIgnored, err.Error()
+		recordHookDelivery(w, d, nil)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid payload", 400)
+		return
+	}
+	d.Event, d.Repo = ev.Kind, ev.Repo
+
+	project, err := hookProject(src, ev)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("⚠️ %s hook for %s matches no project", src.Name, ev.Repo)
+		d.Status, d.Detail = database.HookUnmapped, "no project has repo_url "+ev.Repo
+		if slug := cmp.Or(src.Project(), ev.Project); slug != "" {
+			d.Detail = "no project " + slug
+		}
+		recordHookDelivery(w, d, nil)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Error", 500)
+		return
+	}
+	d.ProjectID = project.ID
+	if project.Archived {
+		d.Status, d.Detail = database.HookIgnored, "project is archived"
+		recordHookDelivery(w, d, nil)
+		return
+	}
+
+	platforms := project.DefaultPlatforms
+	if len(platforms) == 0 {
+		platforms = llm.PlatformNames()
+	}
+	d.Status = database.HookQueued
+	if !recordHookDelivery(w, d, &database.HookJobs{Project: project.Slug, Notes: ev.Notes, Platforms: platforms}) {
+		return
+	}
+	log.Printf("🪝 %s %s on %s queued %d jobs for %s", src.Name, ev.Kind, ev.Repo, len(d.JobIDs), project.Slug)
+}
+
+// hookProject is the project configured for the source, else the one
+// named in the signed payload, else the one whose repo_url is the event's
+// repository
+func hookProject(src hooks.Source, ev *hooks.Event) (*database.Project, error) {
+	if slug := src.Project(); slug != "" {
+		return database.GetProject(database.Slugify(slug))
+	}
+	if ev.Project != "" {
+		return database.GetProject(database.Slugify(ev.Project))
+	}
+	return database.ProjectByRepoURL(ev.RepoURLs)
+}
+
+// recordHookDelivery logs d with its jobs and answers the sender: 202 once
+// logged, 200 for a redelivery, 500 so the sender retries if it couldn't be
+func recordHookDelivery(w http.ResponseWriter, d *database.HookDelivery, jobs *database.HookJobs) bool {
+	err := database.InsertHookDelivery(d, jobs)
+	if errors.Is(err, database.ErrDuplicateHookDelivery) {
+		log.Printf("🔁 %s delivery %s already received, skipping", d.Source, d.DeliveryID)
+		d.ID, d.CreatedAt, d.JobIDs = 0, "", nil
+		d.Status = database.HookDuplicate
+		writeHookDelivery(w, http.StatusOK, d)
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ Hook Delivery Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return false
+	}
+	writeHookDelivery(w, http.StatusAccepted, d)
+	return true
+}
+
+func writeHookDelivery(w http.ResponseWriter, code int, d *database.HookDelivery) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(d)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/webhooks"
+)
+
+// HandleCreateJob enqueues a generation job for the background workers
+func HandleCreateJob(w http.ResponseWriter, r *http.Request) {
+	rawContent := r.FormValue("raw_content")
+	projectName := r.FormValue("project_name")
+	platform := r.FormValue("platform")
+	if platform == "" {
+		platform = r.FormValue("content_type")
+	}
+	priority := strings.ToUpper(r.FormValue("priority"))
+	maxAttempts, _ := strconv.Atoi(r.FormValue("max_attempts"))
+
+	if rawContent == "" {
+		http.Error(w, "Raw content is required", 400)
+		return
+	}
+	if !llm.IsFeedType(platform) {
+		http.Error(w, "Unsupported platform", 400)
+		return
+	}
+	if priority != "" && priority != "NORMAL" && priority != "HIGH" {
+		http.Error(w, "Priority must be NORMAL or HIGH", 400)
+		return
+	}
+
+	project, ok := generationProject(w, r, projectName)
+	if !ok {
+		return
+	}
+	if project != nil {
+		projectName = project.Slug
+	}
+
+	id, err := database.EnqueueJob(projectName, platform, rawContent, priority, maxAttempts)
+	if err != nil {
+		log.Printf("❌ Job Enqueue Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+
+	job, err := database.GetEntry(id)
+	if err != nil {
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+strconv.FormatInt(id, 10))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleGetJob lets clients poll a job's state
+func HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := loadJob(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleRetryJob is the human confirmation for a job parked in PENDING-RETRY or FAILED
+func HandleRetryJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := loadJob(w, r)
+	if !ok {
+		return
+	}
+	if job.Status != database.StatusPendingRetry && job.Status != database.StatusFailed {
+		http.Error(w, "Job is not waiting for a retry", http.StatusConflict)
+		return
+	}
+
+	if err := database.ResetRetryCount(job.ID); err != nil {
+		log.Printf("❌ Job Retry Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+
+	webhooks.EmitJob(webhooks.JobRetry, job.ID, map[string]any{"trigger": "manual", "requested_by": actor(r)})
+
+	job, _ = database.GetEntry(job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func loadJob(w http.ResponseWriter, r *http.Request) (*database.QueueItem, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job id", 400)
+		return nil, false
+	}
+
+	job, err := database.GetEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Job not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Error", 500)
+		return nil, false
+	}
+	if !checkProject(w, r, job.ProjectName) {
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/middleware"
+)
+
+// API keys are managed here or with `vexora keys`. A key's secret is only
+// shown in the response that creates or rotates it. Only admin keys without
+// project restrictions may manage keys: a restricted one could otherwise
+// mint itself an unrestricted key.
+
+// keyResponse is a key together with its secret
+type keyResponse struct {
+	*database.APIKey
+	Key string `json:"key"`
+}
+
+func HandleListKeys(w http.ResponseWriter, r *http.Request) {
+	if !checkUnrestricted(w, r) {
+		return
+	}
+	keys, err := database.ListAPIKeys()
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// HandleCreateKey issues a key from the name, scopes and projects
+// (comma-separated) and expires_in (a duration, default never) fields
+func HandleCreateKey(w http.ResponseWriter, r *http.Request) {
+	if !checkUnrestricted(w, r) {
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "Name is required", 400)
+		return
+	}
+	scopes, err := ParseScopes(r.FormValue("scopes"))
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	var ttl time.Duration
+	if v := r.FormValue("expires_in"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+			http.Error(w, "expires_in must be a positive duration, e.g. 720h", 400)
+			return
+		}
+	}
+
+	key, secret, err := database.CreateAPIKey(name, scopes, ParseProjects(r.FormValue("projects")), ttl)
+	if err != nil {
+		log.Printf("❌ API Key Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	log.Printf("🔑 API key #%d %q created by %s", key.ID, key.Name, actor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(keyResponse{key, secret})
+}
+
+// HandleRotateKey replaces a key; the old one keeps working for the grace
+// field's duration (default none)
+func HandleRotateKey(w http.ResponseWriter, r *http.Request) {
+	if !checkUnrestricted(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid key ID", 400)
+		return
+	}
+	var grace time.Duration
+	if v := r.FormValue("grace"); v != "" {
+		if grace, err = time.ParseDuration(v); err != nil || grace < 0 {
+			http.Error(w, "grace must be a duration, e.g. 24h", 400)
+			return
+		}
+	}
+
+	key, secret, err := database.RotateAPIKey(id, grace)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "API key not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ API Key Rotation Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	log.Printf("🔑 API key #%d rotated to #%d by %s", id, key.ID, actor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(keyResponse{key, secret})
+}
+
+func HandleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	if !checkUnrestricted(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid key ID", 400)
+		return
+	}
+	revoked, err := database.RevokeAPIKey(id)
+	if err != nil {
+		log.Printf("❌ API Key Revocation Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	if !revoked {
+		http.Error(w, "API key not found", 404)
+		return
+	}
+	log.Printf("🔑 API key #%d revoked by %s", id, actor(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ParseScopes reads a comma-separated scope list; at least one is required
+func ParseScopes(raw string) ([]string, error) {
+	var scopes []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || slices.Contains(scopes, s) {
+			continue
+		}
+		if !slices.Contains(database.Scopes, s) {
+			return nil, errors.New("scopes must be among " + strings.Join(database.Scopes, ", "))
+		}
+		scopes = append(scopes, s)
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// ParseProjects reads a comma-separated project list as slugs
+func ParseProjects(raw string) []string {
+	var slugs []string
+	for _, p := range strings.Split(raw, ",") {
+		if slug := database.Slugify(p); slug != "" && !slices.Contains(slugs, slug) {
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+// projectAllowed reports whether the caller's key may act on the project
+// named name. Requests without a key got past the middleware with auth off.
+func projectAllowed(r *http.Request, name string) bool {
+	key := middleware.Caller(r.Context())
+	return key == nil || key.AllowsProject(database.Slugify(name))
+}
+
+// checkUnrestricted writes 403 when the caller's key is restricted to projects
+func checkUnrestricted(w http.ResponseWriter, r *http.Request) bool {
+	if key := middleware.Caller(r.Context()); key != nil && len(key.Projects) > 0 {
+		http.Error(w, "API key is restricted to projects and cannot manage keys", 403)
+		return false
+	}
+	return true
+}
+
+// checkProject writes 403 when the caller's key is restricted to other projects
+func checkProject(w http.ResponseWriter, r *http.Request, name string) bool {
+	if !projectAllowed(r, name) {
+		http.Error(w, "API key is not allowed for this project", 403)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"vexora-studio/internal/llm"
+)
+
+// HandleGetLLMHealth reports the circuit breaker state of each provider in the chain
+func HandleGetLLMHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(llm.Health())
+}
+
+// writeGenerationError maps LLM failures to a status code
+func writeGenerationError(w http.ResponseWriter, err error) {
+	var fe *llm.FinishError
+	var be *llm.BudgetError
+	switch {
+	case errors.As(err, &be):
+		retry := math.Ceil(time.Until(be.ResetsAt()).Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(max(int(retry), 1)))
+		http.Error(w, generationErrorMessage(err), http.StatusTooManyRequests)
+	case errors.Is(err, llm.ErrContentBlocked):
+		http.Error(w, generationErrorMessage(err), http.StatusUnprocessableEntity)
+	case errors.As(err, &fe):
+		http.Error(w, generationErrorMessage(err), http.StatusBadGateway)
+	case errors.Is(err, llm.ErrNoProviderAvailable):
+		http.Error(w, generationErrorMessage(err), http.StatusServiceUnavailable)
+	case errors.Is(err, llm.ErrUnknownVoice):
+		http.Error(w, generationErrorMessage(err), 400)
+	case errors.Is(err, llm.ErrInvalidStructuredOutput):
+		http.Error(w, generationErrorMessage(err), http.StatusBadGateway)
+	default:
+		http.Error(w, generationErrorMessage(err), 500)
+	}
+}
+
+// generationErrorMessage is the client-facing text for an LLM failure; the
+// details only go to the log
+func generationErrorMessage(err error) string {
+	var fe *llm.FinishError
+	var be *llm.BudgetError
+	switch {
+	case errors.As(err, &be):
+		return "Generation budget exceeded: " + be.Error()
+	case errors.Is(err, llm.ErrContentBlocked):
+		return "Content blocked by the LLM's safety filters"
+	case errors.As(err, &fe):
+		return "LLM stopped before finishing (" + fe.Reason + ")"
+	case errors.Is(err, llm.ErrNoProviderAvailable):
+		return "All LLM providers are unavailable"
+	case errors.Is(err, llm.ErrUnknownVoice):
+		return "Unknown voice profile"
+	case errors.Is(err, llm.ErrInvalidStructuredOutput):
+		return "LLM reply did not match the expected format"
+	}
+	return "Content Generation Failed"
+}
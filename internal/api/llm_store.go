@@ -0,0 +1,86 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+)
+
+// LLMStore gives generations the voice profiles, budgets and usage ledger
+// kept in the database; main installs it with llm.SetStore
+type LLMStore struct{}
+
+func (LLMStore) Voice(name string) (*llm.Voice, error) {
+	return toVoice(database.GetVoice(name))
+}
+
+func (LLMStore) ProjectVoice(project string) (*llm.Voice, error) {
+	return toVoice(database.ProjectVoice(project))
+}
+
+func toVoice(v *database.VoiceProfile, err error) (*llm.Voice, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := &llm.Voice{
+		Name:        v.Name,
+		Version:     v.Version,
+		Persona:     v.Persona,
+		Tone:        v.Tone,
+		Audience:    v.Audience,
+		Emoji:       v.Emoji,
+		Signature:   v.Signature,
+		BannedWords: v.BannedWords,
+	}
+	for _, ex := range v.Examples {
+		out.Examples = append(out.Examples, llm.VoiceExample{Platform: ex.Platform, Text: ex.Text})
+	}
+	return out, nil
+}
+
+func (LLMStore) Budgets(project string, now time.Time) ([]llm.BudgetState, error) {
+	budgets, err := database.ProjectBudgets(project)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]llm.BudgetState, 0, len(budgets))
+	for _, b := range budgets {
+		s, err := b.State(now)
+		if err != nil {
+			return nil, err
+		}
+		resets, _ := time.Parse(time.RFC3339, s.ResetsAt)
+		states = append(states, llm.BudgetState{
+			Project:     s.ProjectName,
+			Period:      s.Period,
+			Fallback:    s.Action == database.BudgetFallback,
+			MaxTokens:   s.MaxTokens,
+			MaxCostUSD:  s.MaxCostUSD,
+			UsedTokens:  s.UsedTokens,
+			UsedCostUSD: s.UsedCostUSD,
+			Exceeded:    s.Exceeded,
+			ResetsAt:    resets,
+		})
+	}
+	return states, nil
+}
+
+func (LLMStore) RecordUsage(u llm.UsageRecord) error {
+	return database.InsertUsage(&database.UsageRecord{
+		ProjectName:      u.Project,
+		Platform:         u.Platform,
+		Provider:         u.Provider,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		LatencyMS:        u.Latency.Milliseconds(),
+		CostUSD:          u.CostUSD,
+		Status:           u.Status,
+		Error:            u.Error,
+	})
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+)
+
+// Projects are sent and returned as JSON (see database.Project) and
+// addressed by slug. Generation requests name one through project_name,
+// matched by slug; it must exist.
+
+// HandleListProjects returns the active projects; ?archived=true includes
+// the archived ones
+func HandleListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := database.ListProjects(r.URL.Query().Get("archived") == "true")
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	projects = slices.DeleteFunc(projects, func(p database.Project) bool { return !projectAllowed(r, p.Slug) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
+
+func HandleGetProject(w http.ResponseWriter, r *http.Request) {
+	p, ok := loadProject(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandleCreateProject stores a new project. The slug defaults to one
+// derived from the name. Returns 409 if the slug is taken.
+func HandleCreateProject(w http.ResponseWriter, r *http.Request) {
+	p, ok := decodeProject(w, r)
+	if !ok {
+		return
+	}
+	if p.Slug == "" {
+		p.Slug = database.Slugify(p.Name)
+	}
+	if p.Slug == "" || p.Slug != database.Slugify(p.Slug) {
+		http.Error(w, "Slug must be lowercase letters and digits separated by -", 400)
+		return
+	}
+	if !checkProject(w, r, p.Slug) {
+		return
+	}
+
+	if err := database.InsertProject(p); err != nil {
+		if errors.Is(err, database.ErrProjectExists) {
+			http.Error(w, "Project already exists", 409)
+			return
+		}
+		log.Printf("❌ Project Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	log.Printf("📁 Project %q created", p.Slug)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/projects/"+p.Slug)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandleUpdateProject replaces a project's settings; the slug can't change
+func HandleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	p, ok := decodeProject(w, r)
+	if !ok {
+		return
+	}
+	p.Slug = database.Slugify(r.PathValue("project"))
+	if !checkProject(w, r, p.Slug) {
+		return
+	}
+
+	err := database.UpdateProject(p)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Project not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Project Update Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	log.Printf("📁 Project %q updated", p.Slug)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandleDeleteProject removes a project without feeds, jobs or campaigns.
+// Projects in use respond 409: archive them instead.
+func HandleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return
+	}
+	found, err := database.DeleteProject(database.Slugify(r.PathValue("project")))
+	if errors.Is(err, database.ErrProjectInUse) {
+		http.Error(w, "Project has feeds, jobs or campaigns; archive it instead", 409)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Project Delete Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	if !found {
+		http.Error(w, "Project not found", 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetProjectFeeds returns a project's feeds on every platform, oldest first
+func HandleGetProjectFeeds(w http.ResponseWriter, r *http.Request) {
+	p, ok := loadProject(w, r)
+	if !ok {
+		return
+	}
+	contents, err := database.Contents().ListByProjectID(p.ID)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contents)
+}
+
+// loadProject fetches the project named by the path, writing 404 or 500 on failure
+func loadProject(w http.ResponseWriter, r *http.Request) (*database.Project, bool) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return nil, false
+	}
+	p, err := database.GetProject(database.Slugify(r.PathValue("project")))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Project not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return nil, false
+	}
+	return p, true
+}
+
+// generationProject resolves the project_name of a generation request.
+// Unknown projects respond 404 (create them with POST /projects first) and
+// archived ones 409. A request without a project gets nil.
+func generationProject(w http.ResponseWriter, r *http.Request, name string) (*database.Project, bool) {
+	if !checkProject(w, r, name) {
+		return nil, false
+	}
+	if database.Slugify(name) == "" {
+		return nil, true
+	}
+	p, err := database.GetProject(database.Slugify(name))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Project not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return nil, false
+	}
+	if p.Archived {
+		http.Error(w, "Project is archived", 409)
+		return nil, false
+	}
+	return p, true
+}
+
+// decodeProject reads and checks a project from the JSON body
+func decodeProject(w http.ResponseWriter, r *http.Request) (*database.Project, bool) {
+	var p database.Project
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&p); err != nil {
+		http.Error(w, "Invalid JSON body", 400)
+		return nil, false
+	}
+	if p.Name == "" {
+		http.Error(w, "Name is required", 400)
+		return nil, false
+	}
+	for _, platform := range p.DefaultPlatforms {
+		if !llm.IsFeedType(platform) {
+			http.Error(w, "Unknown platform: "+platform, 400)
+			return nil, false
+		}
+	}
+	if p.VoiceProfile != "" {
+		if _, err := database.GetVoice(p.VoiceProfile); err != nil {
+			http.Error(w, "Unknown voice profile", 400)
+			return nil, false
+		}
+	}
+	return &p, true
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/prompts"
+)
+
+// Prompt templates are addressed by name; the optional project_name
+// parameter (query or form) selects a project's override instead of the
+// global template.
+
+// HandleListPrompts returns the template in effect for every prompt
+func HandleListPrompts(w http.ResponseWriter, r *http.Request) {
+	project := database.Slugify(r.FormValue("project_name"))
+	var templates []prompts.Template
+	for _, name := range prompts.Names() {
+		t, err := prompts.Resolve(name, project)
+		if err != nil {
+			log.Printf("❌ Prompt Resolve Failed: %v", err)
+			http.Error(w, "Database Retrieval Failed", 500)
+			return
+		}
+		templates = append(templates, t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// HandleGetPrompt returns the template in effect for one prompt
+func HandleGetPrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptName(w, r)
+	if !ok {
+		return
+	}
+	t, err := prompts.Resolve(name, database.Slugify(r.FormValue("project_name")))
+	if err != nil {
+		log.Printf("❌ Prompt Resolve Failed: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// HandleListPromptVersions lists the stored versions of a prompt, newest first
+func HandleListPromptVersions(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptName(w, r)
+	if !ok {
+		return
+	}
+	versions, err := database.ListPromptVersions(name, database.Slugify(r.FormValue("project_name")))
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	if versions == nil {
+		versions = []database.PromptTemplate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func HandleGetPromptVersion(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptName(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		http.Error(w, "Invalid version", 400)
+		return
+	}
+	p, err := database.GetPromptVersion(name, database.Slugify(r.FormValue("project_name")), version)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Prompt version not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandleSavePrompt stores "body" as a new version. The template must parse
+// and render with sample data.
+func HandleSavePrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptName(w, r)
+	if !ok {
+		return
+	}
+	if !checkProject(w, r, r.FormValue("project_name")) {
+		return
+	}
+	body := r.FormValue("body")
+	if body == "" {
+		http.Error(w, "Body is required", 400)
+		return
+	}
+	if err := prompts.Validate(name, body); err != nil {
+		http.Error(w, "Invalid template: "+err.Error(), 400)
+		return
+	}
+
+	p, err := database.InsertPromptVersion(name, database.Slugify(r.FormValue("project_name")), body, actor(r))
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	t := prompts.FromDB(p)
+	log.Printf("📝 Prompt %s saved by %s", t.ID(), p.Author)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// HandleDeletePrompt archives every stored version for the scope, so the
+// global or built-in template applies again. Old versions stay readable.
+func HandleDeletePrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptName(w, r)
+	if !ok {
+		return
+	}
+	project := database.Slugify(r.FormValue("project_name"))
+	if !checkProject(w, r, project) {
+		return
+	}
+	n, err := database.ArchivePrompts(name, project)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "No stored versions to delete", 404)
+		return
+	}
+	t, err := prompts.Resolve(name, project)
+	if err != nil {
+		log.Printf("❌ Prompt Resolve Failed: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"archived": n, "effective": t})
+}
+
+func promptName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if !prompts.IsName(name) {
+		http.Error(w, "Unknown prompt template", 404)
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/diff"
+	"vexora-studio/internal/llm"
+)
+
+// HandleListRevisions returns the text history of a feed, including the
+// versions it was regenerated into or from, oldest first
+func HandleListRevisions(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, ok := loadContent(w, r, platform)
+		if !ok {
+			return
+		}
+		revisions, err := database.Contents().Revisions(content.ID)
+		if err != nil {
+			log.Printf("❌ DB Error: %v", err)
+			http.Error(w, "Database Retrieval Failed", 500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(revisions)
+	}
+}
+
+func HandleGetRevision(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, ok := loadContent(w, r, platform)
+		if !ok {
+			return
+		}
+		rev, ok := loadRevision(w, content.ID, r.PathValue("rev"))
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rev)
+	}
+}
+
+// HandleDiffRevisions compares two revisions: ?from=&to=&mode=unified|word.
+// By default it compares the feed's first revision (what the model wrote)
+// with its latest one (what it is now).
+func HandleDiffRevisions(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, ok := loadContent(w, r, platform)
+		if !ok {
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "unified"
+		}
+		if mode != "unified" && mode != "word" {
+			http.Error(w, "Mode must be unified or word", 400)
+			return
+		}
+
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			first, last, err := ownRevisions(content.ID)
+			if err != nil {
+				log.Printf("❌ DB Error: %v", err)
+				http.Error(w, "Database Retrieval Failed", 500)
+				return
+			}
+			if from == "" {
+				from = strconv.FormatInt(first, 10)
+			}
+			if to == "" {
+				to = strconv.FormatInt(last, 10)
+			}
+		}
+		a, ok := loadRevision(w, content.ID, from)
+		if !ok {
+			return
+		}
+		b, ok := loadRevision(w, content.ID, to)
+		if !ok {
+			return
+		}
+
+		before, after := diffable(platform, a.Output), diffable(platform, b.Output)
+		resp := map[string]any{"from": a.ID, "to": b.ID, "mode": mode}
+		if mode == "word" {
+			resp["segments"] = diff.Words(before, after)
+		} else {
+			resp["diff"] = diff.Unified(revisionLabel(a), revisionLabel(b), before, after, 3)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// HandleRestoreRevision makes an old revision the feed's current text. The
+// history is append-only, so this adds a new "restored" revision.
+func HandleRestoreRevision(platform string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, ok := loadContent(w, r, platform)
+		if !ok {
+			return
+		}
+		old, ok := loadRevision(w, content.ID, r.PathValue("rev"))
+		if !ok {
+			return
+		}
+
+		rev, err := database.Contents().Revise(content.ID, old.Output, database.RevisionRestored, actor(r), old.ID)
+		if err != nil {
+			log.Printf("❌ Revision Insert Failed: %v", err)
+			http.Error(w, "Database Insertion Failed", 500)
+			return
+		}
+		log.Printf("⏪ %s #%d restored to revision %d by %s", platform, content.ID, old.ID, rev.Author)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/%s/%d/revisions/%d", platform, content.ID, rev.ID))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rev)
+	}
+}
+
+// loadContent resolves the {id} path value of a platform route
+func loadContent(w http.ResponseWriter, r *http.Request, platform string) (*database.Content, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return nil, false
+	}
+	content, err := database.Contents().GetByID(platform, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Feed not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return nil, false
+	}
+	if !checkProject(w, r, content.ProjectName) {
+		return nil, false
+	}
+	return content, true
+}
+
+func loadRevision(w http.ResponseWriter, contentID int64, idStr string) (*database.Revision, bool) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision ID", 400)
+		return nil, false
+	}
+	rev, err := database.Contents().Revision(contentID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Revision not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return nil, false
+	}
+	return rev, true
+}
+
+// ownRevisions returns the first and latest revision of this feed itself
+func ownRevisions(contentID int64) (first, last int64, err error) {
+	revisions, err := database.Contents().Revisions(contentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, rev := range revisions {
+		if rev.ContentID != contentID {
+			continue
+		}
+		if first == 0 {
+			first = rev.ID
+		}
+		last = rev.ID
+	}
+	return first, last, nil
+}
+
+// diffable turns a newsletter's JSON into plain lines, and a thread into
+// one block per tweet, so the diff shows what changed instead of one long line
+func diffable(platform, output string) string {
+	if platform == llm.TypeTwitter {
+		if tweets, err := llm.ThreadTweets(output); err == nil {
+			return strings.Join(tweets, "\n\n") + "\n"
+		}
+	}
+	if platform != llm.TypeNewsletter {
+		return output
+	}
+	var nl struct {
+		Subject string   `json:"subject_line"`
+		Preview string   `json:"preview_text"`
+		Tags    []string `json:"tags"`
+		Body    string   `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(output), &nl); err != nil {
+		return output
+	}
+	return fmt.Sprintf("Subject: %s\nPreview: %s\nTags: %s\n\n%s\n", nl.Subject, nl.Preview, strings.Join(nl.Tags, ", "), nl.Body)
+}
+
+func revisionLabel(rev *database.Revision) string {
+	return fmt.Sprintf("revision %d (%s by %s, %s)", rev.ID, rev.Kind, rev.Author, rev.CreatedAt)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+
+	"vexora-studio/internal/llm"
+)
+
+func TestDiffable(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		output   string
+		want     string
+	}{
+		{"newsletter", llm.TypeNewsletter,
+			`{"subject_line": "v2 is out", "preview_text": "What changed", "tags": ["release", "go"], "body": "# v2\n\nFaster."}`,
+			"Subject: v2 is out\nPreview: What changed\nTags: release, go\n\n# v2\n\nFaster.\n"},
+		{"newsletter without tags", llm.TypeNewsletter,
+			`{"subject_line": "v2", "preview_text": "", "body": "Faster."}`,
+			"Subject: v2\nPreview: \nTags: \n\nFaster.\n"},
+		{"newsletter that isn't JSON", llm.TypeNewsletter, "Plain text", "Plain text"},
+		{"thread", llm.TypeTwitter, `["1/2 We shipped v2.", "2/2 Try it today."]`,
+			"1/2 We shipped v2.\n\n2/2 Try it today.\n"},
+		{"single tweet", llm.TypeTwitter, "We shipped v2. #golang #release", "We shipped v2. #golang #release"},
+		{"other platform", llm.TypeLinkedIn, `["not", "a thread"]`, `["not", "a thread"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffable(tt.platform, tt.output); got != tt.want {
+				t.Errorf("diffable = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
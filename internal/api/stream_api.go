@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+)
+
+// HandleStreamFeed returns a handler that streams generation as Server-Sent Events:
+//
+//	event: token  data: {"text": "..."}
+//	event: done   data: {"id": 1, "feed": "...", "provider": "...", "model": "...", "validation": {...}}
+//	event: error  data: {"error": "..."}
+//
+// The feed is stored only once the stream completes. Closing the connection
+// cancels the upstream LLM request and nothing is saved. Output breaking the
+// platform's rules is repaired before "done", so "feed" can differ from the
+// streamed tokens.
+func HandleStreamFeed(feedType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawContent := r.FormValue("raw_content")
+
+		if rawContent == "" {
+			http.Error(w, "Raw content is required", 400)
+			return
+		}
+		opts, err := generationOptions(r, llm.Options{})
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		project, ok := generationProject(w, r, r.FormValue("project_name"))
+		if !ok {
+			return
+		}
+		in := generationInput(r, rawContent, project)
+		if in.Thread, err = threadOptions(r, feedType, nil); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// Streams can outlive the server's WriteTimeout; keep the connection open
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("⚠️ Could not clear write deadline: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+
+		send := func(event string, payload any) error {
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		ctx := r.Context()
+		res, err := llm.StreamContent(ctx, feedType, in, opts, func(tok string) error {
+			return send("token", map[string]string{"text": tok})
+		})
+		if ctx.Err() != nil {
+			log.Printf("🛑 %s stream cancelled by client", feedType)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ %s Stream Failed: %v", feedType, err)
+			send("error", map[string]string{"error": generationErrorMessage(err)})
+			return
+		}
+
+		content := newContent(feedType, in.Project, rawContent, res)
+		if err := database.Contents().Insert(content); err != nil {
+			log.Printf("❌ %s DB Insert Failed: %v", feedType, err)
+			send("error", map[string]string{"error": "Database Insertion Failed"})
+			return
+		}
+		emitGenerated(content)
+
+		send("done", map[string]any{
+			"id":         content.ID,
+			"feed":       res.Text,
+			"provider":   res.Provider,
+			"model":      res.Model,
+			"validation": res.Validation,
+		})
+	}
+}
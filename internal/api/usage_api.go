@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"vexora-studio/internal/database"
+)
+
+// HandleGetUsage reports LLM token usage and estimated cost from the call
+// ledger. Query parameters:
+//
+//	group_by   comma-separated project, platform, provider, model, day
+//	           (default project,platform,provider,day)
+//	from, to   inclusive UTC days, YYYY-MM-DD (default the last 30 days)
+//	project, platform, provider  filters; project is required for API
+//	           keys restricted to projects
+func HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := database.UsageFilter{
+		From:        q.Get("from"),
+		To:          q.Get("to"),
+		ProjectName: database.Slugify(q.Get("project")),
+		Platform:    q.Get("platform"),
+		Provider:    q.Get("provider"),
+		GroupBy:     []string{"project", "platform", "provider", "day"},
+	}
+	// a key restricted to projects must name one of them
+	if !checkProject(w, r, f.ProjectName) {
+		return
+	}
+	if f.From == "" && f.To == "" {
+		f.From = time.Now().UTC().AddDate(0, 0, -29).Format(time.DateOnly)
+	}
+	for _, day := range []string{f.From, f.To} {
+		if _, err := time.Parse(time.DateOnly, day); day != "" && err != nil {
+			http.Error(w, "from and to must be dates (YYYY-MM-DD)", 400)
+			return
+		}
+	}
+	if v := q.Get("group_by"); v != "" {
+		f.GroupBy = nil
+		for _, g := range strings.Split(v, ",") {
+			g = strings.TrimSpace(g)
+			if _, ok := database.UsageGroups[g]; !ok {
+				http.Error(w, "group_by must list project, platform, provider, model or day", 400)
+				return
+			}
+			f.GroupBy = append(f.GroupBy, g)
+		}
+	}
+
+	rows, err := database.UsageReport(f)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	var total database.UsageRow
+	for _, row := range rows {
+		total.Calls += row.Calls
+		total.FailedCalls += row.FailedCalls
+		total.PromptTokens += row.PromptTokens
+		total.CompletionTokens += row.CompletionTokens
+		total.CostUSD += row.CostUSD
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"from":     f.From,
+		"to":       f.To,
+		"group_by": f.GroupBy,
+		"rows":     rows,
+		"total":    total,
+	})
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+)
+
+// Voice profiles are sent and returned as JSON (see database.VoiceProfile).
+// A generation request picks one with the voice_profile field; otherwise
+// the profile attached to its project applies.
+
+var voiceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func HandleListVoices(w http.ResponseWriter, r *http.Request) {
+	voices, err := database.ListVoices()
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(voices)
+}
+
+func HandleGetVoice(w http.ResponseWriter, r *http.Request) {
+	v, err := database.GetVoice(r.PathValue("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Voice profile not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleCreateVoice stores a new profile. Returns 409 if the name is taken.
+func HandleCreateVoice(w http.ResponseWriter, r *http.Request) {
+	v, ok := decodeVoice(w, r)
+	if !ok {
+		return
+	}
+	if !voiceNamePattern.MatchString(v.Name) {
+		http.Error(w, "Name must be lowercase letters, digits, - or _", 400)
+		return
+	}
+
+	if err := database.InsertVoice(v); err != nil {
+		if errors.Is(err, database.ErrVoiceExists) {
+			http.Error(w, "Voice profile already exists", 409)
+			return
+		}
+		log.Printf("❌ Voice Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	log.Printf("🎙️ Voice profile %q created", v.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/voices/"+v.Name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleUpdateVoice replaces a profile and bumps its version
+func HandleUpdateVoice(w http.ResponseWriter, r *http.Request) {
+	v, ok := decodeVoice(w, r)
+	if !ok {
+		return
+	}
+	v.Name = r.PathValue("name")
+
+	err := database.UpdateVoice(v)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Voice profile not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Voice Update Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	log.Printf("🎙️ Voice profile %q updated to v%d", v.Name, v.Version)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleDeleteVoice removes a profile and detaches it from its projects
+func HandleDeleteVoice(w http.ResponseWriter, r *http.Request) {
+	found, err := database.DeleteVoice(r.PathValue("name"))
+	if err != nil {
+		log.Printf("❌ Voice Delete Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	if !found {
+		http.Error(w, "Voice profile not found", 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetProjectVoice returns the profile attached to a project
+func HandleGetProjectVoice(w http.ResponseWriter, r *http.Request) {
+	if !checkProject(w, r, r.PathValue("project")) {
+		return
+	}
+	v, err := database.ProjectVoice(r.PathValue("project"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Project has no voice profile", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleSetProjectVoice attaches the profile named by the voice_profile
+// field to a project; an empty value detaches it
+func HandleSetProjectVoice(w http.ResponseWriter, r *http.Request) {
+	project, name := r.PathValue("project"), r.FormValue("voice_profile")
+	if !checkProject(w, r, project) {
+		return
+	}
+
+	err := database.SetProjectVoice(project, name)
+	if errors.Is(err, database.ErrUnknownProject) {
+		http.Error(w, "Project not found", 404)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Voice profile not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Project Voice Update Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeVoice reads and checks a profile from the JSON body
+func decodeVoice(w http.ResponseWriter, r *http.Request) (*database.VoiceProfile, bool) {
+	var v database.VoiceProfile
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&v); err != nil {
+		http.Error(w, "Invalid JSON body", 400)
+		return nil, false
+	}
+	switch v.Emoji {
+	case "", database.EmojiNone, database.EmojiLight, database.EmojiHeavy:
+	default:
+		http.Error(w, "emoji must be none, light or heavy", 400)
+		return nil, false
+	}
+	for _, ex := range v.Examples {
+		if strings.TrimSpace(ex.Text) == "" {
+			http.Error(w, "Example posts need text", 400)
+			return nil, false
+		}
+		if ex.Platform != "" && !llm.IsFeedType(ex.Platform) {
+			http.Error(w, "Unknown example platform: "+ex.Platform, 400)
+			return nil, false
+		}
+	}
+	return &v, true
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/webhooks"
+)
+
+// Outbound webhooks are sent and returned as JSON (see database.Webhook).
+// The signing secret is only shown by create and rotate.
+
+// HandleListWebhooks returns every subscription the caller's key may see
+func HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := database.ListWebhooks()
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+	hooks = slices.DeleteFunc(hooks, func(h database.Webhook) bool { return !projectAllowed(r, h.Project) })
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+func HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	h, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	h.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h)
+}
+
+// HandleCreateWebhook subscribes a URL to events and returns the secret
+// its deliveries are signed with
+func HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	h, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.InsertWebhook(h); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Project not found", 400)
+			return
+		}
+		log.Printf("❌ Webhook Insert Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	log.Printf("📤 Webhook #%d created for %s", h.ID, h.URL)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/webhooks/"+strconv.FormatInt(h.ID, 10))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h)
+}
+
+// HandleUpdateWebhook replaces a subscription's settings; the secret is
+// kept, see HandleRotateWebhookSecret
+func HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	old, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	h, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+	h.ID = old.ID
+
+	err := database.UpdateWebhook(h)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Project not found", 400)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Webhook Update Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	h.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h)
+}
+
+// HandleRotateWebhookSecret replaces the signing secret and returns the new
+// one. Receivers should accept both until they have switched over.
+func HandleRotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	old, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	h, err := database.RotateWebhookSecret(old.ID)
+	if err != nil {
+		log.Printf("❌ Webhook Rotate Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	log.Printf("🔑 Webhook #%d secret rotated", h.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h)
+}
+
+// HandleDeleteWebhook removes a subscription along with its delivery log
+func HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	h, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	if _, err := database.DeleteWebhook(h.ID); err != nil {
+		log.Printf("❌ Webhook Delete Failed: %v", err)
+		http.Error(w, "Database Update Failed", 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListWebhookDeliveries returns the latest deliveries of a
+// subscription without their payloads; ?status= filters (pending,
+// delivered, failed) and ?limit= defaults to 50
+func HandleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	h, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > 500 {
+			http.Error(w, "limit must be between 1 and 500", 400)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := database.ListWebhookDeliveries(h.ID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// HandleGetWebhookDelivery returns one delivery with its payload and the
+// receiver's last response
+func HandleGetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	h, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("delivery"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery id", 400)
+		return
+	}
+	d, err := database.GetWebhookDelivery(h.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Delivery not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// HandleRedeliverWebhook queues a delivery again as a new delivery
+func HandleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	h, ok := loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("delivery"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery id", 400)
+		return
+	}
+	d, err := database.RedeliverWebhook(h.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Delivery not found", 404)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Webhook Redeliver Failed: %v", err)
+		http.Error(w, "Database Insertion Failed", 500)
+		return
+	}
+	webhooks.Wake()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(d)
+}
+
+// loadWebhook fetches the subscription named by the path, writing 400, 403,
+// 404 or 500 on failure
+func loadWebhook(w http.ResponseWriter, r *http.Request) (*database.Webhook, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook id", 400)
+		return nil, false
+	}
+	h, err := database.GetWebhook(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Webhook not found", 404)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Retrieval Failed", 500)
+		return nil, false
+	}
+	if !checkProject(w, r, h.Project) {
+		return nil, false
+	}
+	return h, true
+}
+
+// decodeWebhook reads and checks a subscription from the JSON body. Active
+// defaults to true.
+func decodeWebhook(w http.ResponseWriter, r *http.Request) (*database.Webhook, bool) {
+	var body struct {
+		URL         string   `json:"url"`
+		Events      []string `json:"events"`
+		Project     string   `json:"project"`
+		Description string   `json:"description"`
+		Active      *bool    `json:"active"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON body", 400)
+		return nil, false
+	}
+	u, err := url.Parse(body.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be an absolute http(s) URL", 400)
+		return nil, false
+	}
+	if len(body.Events) == 0 {
+		http.Error(w, "At least one event is required", 400)
+		return nil, false
+	}
+	for _, event := range body.Events {
+		if !webhooks.IsEvent(event) {
+			http.Error(w, "Unknown event: "+event, 400)
+			return nil, false
+		}
+	}
+	slices.Sort(body.Events)
+	h := &database.Webhook{
+		URL:         body.URL,
+		Events:      slices.Compact(body.Events),
+		Project:     database.Slugify(body.Project),
+		Description: body.Description,
+		Active:      body.Active == nil || *body.Active,
+	}
+	if !checkProject(w, r, h.Project) {
+		return nil, false
+	}
+	return h, true
+}
+
+// emitGenerated announces a feed generated by a request
+func emitGenerated(content *database.Content) {
+	webhooks.Emit(webhooks.ContentGenerated, content.ProjectName, map[string]any{"content": content})
+}
@@ -0,0 +1,90 @@
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions a signed link can authorise
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+	ActionEdit    = "edit"
+)
+
+var (
+	ErrExpired      = errors.New("approval link expired")
+	ErrBadSignature = errors.New("invalid approval signature")
+)
+
+// NewToken returns a random 256-bit token, hex encoded. It is stored on the
+// job and is the HMAC key for every link signed for that job.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// TTL is how long a job stays approvable, from APPROVAL_TTL (default 72h)
+func TTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("APPROVAL_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 72 * time.Hour
+}
+
+// Sign returns the hex HMAC-SHA256 of "id:action:expires" keyed by the job token
+func Sign(token string, id int64, action string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	fmt.Fprintf(mac, "%d:%s:%d", id, action, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a link's signature with a constant-time compare, then its expiry
+func Verify(token string, id int64, action string, expires int64, sig string, now time.Time) error {
+	if token == "" || sig == "" {
+		return ErrBadSignature
+	}
+	expected := Sign(token, id, action, expires)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sig))) {
+		return ErrBadSignature
+	}
+	if now.Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Query returns the exp/sig query string for a signed action link
+func Query(token string, id int64, action string, expires time.Time) string {
+	exp := expires.Unix()
+	v := url.Values{}
+	v.Set("exp", strconv.FormatInt(exp, 10))
+	v.Set("sig", Sign(token, id, action, exp))
+	return v.Encode()
+}
+
+// Path is the relative URL of a signed action, e.g. /jobs/7/approve?exp=...&sig=...
+func Path(token string, id int64, action string, expires time.Time) string {
+	return fmt.Sprintf("/jobs/%d/%s?%s", id, action, Query(token, id, action, expires))
+}
+
+// Link is Path prefixed with APP_BASE_URL (default http://localhost:8081), for emails
+func Link(token string, id int64, action string, expires time.Time) string {
+	base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if base == "" {
+		base = "http://localhost:8081"
+	}
+	return base + Path(token, id, action, expires)
+}
@@ -0,0 +1,70 @@
+package approval
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	exp := now.Add(time.Hour).Unix()
+	sig := Sign("tok", 7, ActionApprove, exp)
+
+	tests := []struct {
+		name   string
+		token  string
+		id     int64
+		action string
+		exp    int64
+		sig    string
+		want   error
+	}{
+		{"valid", "tok", 7, ActionApprove, exp, sig, nil},
+		{"uppercase hex", "tok", 7, ActionApprove, exp, strings.ToUpper(sig), nil},
+		{"expires now", "tok", 7, ActionApprove, now.Unix(), Sign("tok", 7, ActionApprove, now.Unix()), nil},
+		{"expired", "tok", 7, ActionApprove, now.Unix() - 1, Sign("tok", 7, ActionApprove, now.Unix()-1), ErrExpired},
+		{"other job", "tok", 8, ActionApprove, exp, sig, ErrBadSignature},
+		{"other action", "tok", 7, ActionReject, exp, sig, ErrBadSignature},
+		{"extended expiry", "tok", 7, ActionApprove, exp + 3600, sig, ErrBadSignature},
+		{"other token", "rotated", 7, ActionApprove, exp, sig, ErrBadSignature},
+		{"no token", "", 7, ActionApprove, exp, Sign("", 7, ActionApprove, exp), ErrBadSignature},
+		{"no signature", "tok", 7, ActionApprove, exp, "", ErrBadSignature},
+		{"truncated", "tok", 7, ActionApprove, exp, sig[:32], ErrBadSignature},
+		{"expired and forged", "tok", 7, ActionApprove, now.Unix() - 1, sig, ErrBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.token, tt.id, tt.action, tt.exp, tt.sig, now)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathVerifies(t *testing.T) {
+	token, err := NewToken()
+	if err != nil || len(token) != 64 {
+		t.Fatalf("NewToken = %q, %v", token, err)
+	}
+	expires := time.Now().Add(TTL())
+
+	for _, action := range []string{ActionApprove, ActionReject, ActionEdit} {
+		u, err := url.Parse(Path(token, 42, action, expires))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "/jobs/42/" + action; u.Path != want {
+			t.Errorf("path = %q, want %q", u.Path, want)
+		}
+		q := u.Query()
+		exp, _ := strconv.ParseInt(q.Get("exp"), 10, 64)
+		if err := Verify(token, 42, action, exp, q.Get("sig"), time.Now()); err != nil {
+			t.Errorf("%s link doesn't verify: %v", action, err)
+		}
+	}
+}
@@ -1,10 +1,15 @@
-package dashboard  
+package dashboard
 
 import (
 	"html/template"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"vexora-studio/internal/api"
+	"vexora-studio/internal/approval"
 	"vexora-studio/internal/database"
+	"vexora-studio/internal/middleware"
 )
 
 // We embed the HTML in the binary so you only need one executable
@@ -58,12 +63,15 @@ const htmlTemplate = `
                     <div class="p-4 border-b flex justify-between items-center bg-gray-50">
                         <div>
                             <h2 class="text-lg font-bold">{{.SelectedJob.GeneratedSubject}}</h2>
-                            <p class="text-xs text-gray-500">ID: {{.SelectedJob.ID}} • Type: Newsletter</p>
+                            <p class="text-xs text-gray-500">ID: {{.SelectedJob.ID}} • Type: {{.SelectedJob.FeedType}}</p>
                         </div>
+                        {{if .CanReview}}
                         <div class="space-x-2">
-                            <button onclick="rejectJob({{.SelectedJob.ID}})" class="px-4 py-2 bg-red-100 text-red-700 rounded hover:bg-red-200 font-medium">Reject</button>
-                            <button onclick="approveJob({{.SelectedJob.ID}})" class="px-4 py-2 bg-green-600 text-white rounded hover:bg-green-700 font-medium shadow-md">Approve & Publish</button>
+                            <button onclick="saveJob()" class="px-4 py-2 bg-gray-100 text-gray-700 rounded hover:bg-gray-200 font-medium">Save Edits</button>
+                            <button onclick="rejectJob()" class="px-4 py-2 bg-red-100 text-red-700 rounded hover:bg-red-200 font-medium">Reject</button>
+                            <button onclick="approveJob()" class="px-4 py-2 bg-green-600 text-white rounded hover:bg-green-700 font-medium shadow-md">Approve & Publish</button>
                         </div>
+                        {{end}}
                     </div>
 
                     <div class="flex flex-1 overflow-hidden">
@@ -101,18 +109,53 @@ const htmlTemplate = `
             updatePreview();
         }
 
-        // API Calls
-        async function approveJob(id) {
+        // API Calls (signed links with auth off, otherwise the browser's key)
+        const links = {
+            approve: {{.ApproveURL}},
+            reject: {{.RejectURL}},
+            edit: {{.EditURL}},
+        };
+        const original = document.getElementById('editor') ? document.getElementById('editor').value : '';
+
+        async function post(url, fields) {
+            const body = new FormData();
+            Object.entries(fields || {}).forEach(([k, v]) => body.append(k, v));
+            const res = await fetch(url, { method: 'POST', body });
+            if (!res.ok) throw new Error(await res.text());
+            return res.json();
+        }
+
+        async function saveJob() {
+            try {
+                await post(links.edit, { content: document.getElementById('editor').value });
+                location.reload();
+            } catch (e) {
+                alert('Save failed: ' + e.message);
+            }
+        }
+
+        async function approveJob() {
             if(!confirm("Ready to approve?")) return;
-            // You'd need to fetch the token from the UI logic or update API to be cookie-based
-            // For local dev, we can simplify or assume a mock token for now
-            alert("Approval Logic triggered for " + id);
-            // fetch('/approve?id=' + id ...);
+            try {
+                // Keep the reviewer's edits before approving
+                const current = document.getElementById('editor').value;
+                if (current !== original) await post(links.edit, { content: current });
+                await post(links.approve);
+                location.href = '/dashboard';
+            } catch (e) {
+                alert('Approval failed: ' + e.message);
+            }
         }
-        
-        async function rejectJob(id) {
+
+        async function rejectJob() {
             if(!confirm("Reject and retry?")) return;
-            alert("Reject Logic triggered for " + id);
+            const reason = prompt("Reason (optional)") || '';
+            try {
+                await post(links.reject, { reason });
+                location.href = '/dashboard';
+            } catch (e) {
+                alert('Reject failed: ' + e.message);
+            }
         }
     </script>
 </body>
@@ -120,43 +163,121 @@ const htmlTemplate = `
 `
 
 type PageData struct {
-	Jobs        []database.QueueItem // We'll need to update QueueItem to have all fields
+	Jobs        []database.QueueItem
 	SelectedJob *database.QueueItem
 	SelectedID  int64
+
+	// Whether the caller may approve, reject and edit
+	CanReview bool
+
+	// Action links for the selected job, signed when auth is off
+	ApproveURL string
+	RejectURL  string
+	EditURL    string
 }
 
+// StartDashboard serves the review page on its own mux. With auth on the
+// page needs a key with the read scope, sent by the browser as the Basic
+// password, and reviewing needs the approve scope; the actions then go to
+// plain paths authorized by that key. Only with auth off does the page
+// carry signed links.
 func StartDashboard(port string) {
-	http.HandleFunc("/dashboard", func(w http.ResponseWriter, r *http.Request) {
-		// 1. Fetch All "WAITING_APPROVAL" Jobs
-		jobs, _ := database.GetJobsByStatus("WAITING_APPROVAL")
-		
-		// 2. Determine Selected Job
-		var selected *database.QueueItem
-		idStr := r.URL.Query().Get("id")
-		selectedID, _ := strconv.ParseInt(idStr, 10, 64)
-
-		if selectedID != 0 {
-			// Find it in the list (or query DB directly)
-			for i := range jobs {
-				if jobs[i].ID == selectedID {
-					selected = &jobs[i]
-					break
-				}
+	go http.ListenAndServe(port, newMux())
+}
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// The review actions are served here too so the page can call them
+	// same-origin. The browser sends its cached key with any request, so
+	// other sites' forms must not reach them.
+	review := func(h http.HandlerFunc) http.HandlerFunc {
+		return sameOrigin(middleware.Browser(middleware.OptionalKey(h)))
+	}
+	mux.HandleFunc("POST /jobs/{id}/approve", review(api.HandleApproveJob))
+	mux.HandleFunc("POST /jobs/{id}/reject", review(api.HandleRejectJob))
+	mux.HandleFunc("POST /jobs/{id}/edit", review(api.HandleEditJob))
+
+	mux.HandleFunc("/dashboard", middleware.Browser(middleware.RequireScope(database.ScopeRead, handleDashboard)))
+	return mux
+}
+
+// sameOrigin refuses requests a browser made from another site, going by
+// Sec-Fetch-Site, else Origin. Requests with neither come from outside a
+// browser and carry their own credentials.
+func sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		site := r.Header.Get("Sec-Fetch-Site")
+		origin := r.Header.Get("Origin")
+		cross := false
+		switch {
+		case site != "":
+			cross = site != "same-origin" && site != "none"
+		case origin != "":
+			u, err := url.Parse(origin)
+			cross = err != nil || u.Host != r.Host
+		}
+		if cross {
+			log.Printf("⛔ Security Alert: cross-site %s %s from %s (origin %q)", r.Method, r.URL.Path, r.RemoteAddr, origin)
+			http.Error(w, "Cross-site request refused", 403)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func handleDashboard(w http.ResponseWriter, r *http.Request) {
+	key := middleware.Caller(r.Context())
+
+	// 1. Fetch the "WAITING_APPROVAL" Jobs the caller may see
+	all, _ := database.GetJobsByStatus("WAITING_APPROVAL")
+	var jobs []database.QueueItem
+	for _, j := range all {
+		if key == nil || key.AllowsProject(database.Slugify(j.ProjectName)) {
+			jobs = append(jobs, j)
+		}
+	}
+
+	// 2. Determine Selected Job
+	var selected *database.QueueItem
+	idStr := r.URL.Query().Get("id")
+	selectedID, _ := strconv.ParseInt(idStr, 10, 64)
+
+	if selectedID != 0 {
+		// Find it in the list (or query DB directly)
+		for i := range jobs {
+			if jobs[i].ID == selectedID {
+				selected = &jobs[i]
+				break
 			}
-		} else if len(jobs) > 0 {
-			// Default to first
-			selected = &jobs[0]
-			selectedID = selected.ID
 		}
+	} else if len(jobs) > 0 {
+		// Default to first
+		selected = &jobs[0]
+		selectedID = selected.ID
+	}
 
-		// 3. Render
-		tmpl, _ := template.New("dash").Parse(htmlTemplate)
-		tmpl.Execute(w, PageData{
-			Jobs:        jobs,
-			SelectedJob: selected,
-			SelectedID:  selectedID,
-		})
-	})
-
-	go http.ListenAndServe(port, nil)
-}
\ No newline at end of file
+	data := PageData{
+		Jobs:        jobs,
+		SelectedJob: selected,
+		SelectedID:  selectedID,
+		CanReview:   key == nil || key.HasScope(database.ScopeApprove),
+	}
+	if selected != nil && data.CanReview {
+		if middleware.AuthEnabled() {
+			base := "/jobs/" + strconv.FormatInt(selected.ID, 10) + "/"
+			data.ApproveURL = base + approval.ActionApprove
+			data.RejectURL = base + approval.ActionReject
+			data.EditURL = base + approval.ActionEdit
+		} else {
+			expires := selected.ApprovalExpiry()
+			data.ApproveURL = approval.Path(selected.ApprovalToken, selected.ID, approval.ActionApprove, expires)
+			data.RejectURL = approval.Path(selected.ApprovalToken, selected.ID, approval.ActionReject, expires)
+			data.EditURL = approval.Path(selected.ApprovalToken, selected.ID, approval.ActionEdit, expires)
+		}
+	}
+
+	// 3. Render
+	tmpl, _ := template.New("dash").Parse(htmlTemplate)
+	tmpl.Execute(w, data)
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+// API key scopes. Admin implies the others.
+const (
+	ScopeRead     = "read"     // fetch feeds, jobs, campaigns and settings
+	ScopeGenerate = "generate" // generate feeds and enqueue jobs
+	ScopeApprove  = "approve"  // approve, reject and edit jobs without a signed link
+	ScopeAdmin    = "admin"    // manage projects, voices, prompts, budgets and keys
+)
+
+// Scopes lists every scope, in order of privilege
+var Scopes = []string{ScopeRead, ScopeGenerate, ScopeApprove, ScopeAdmin}
+
+// APIKey is a REST API credential. The secret itself is only returned once,
+// when the key is created or rotated.
+type APIKey struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Scopes      []string `json:"scopes"`
+	Projects    []string `json:"projects,omitempty"` // project slugs; empty allows every project
+	RotatedFrom int64    `json:"rotated_from,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	LastUsedAt  string   `json:"last_used_at,omitempty"`
+	RevokedAt   string   `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// AllowsProject reports whether the key may act on the project with this
+// slug. Restricted keys can't act outside any project.
+func (k *APIKey) AllowsProject(slug string) bool {
+	return len(k.Projects) == 0 || slices.Contains(k.Projects, slug)
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, projects, COALESCE(rotated_from, 0), created_at,
+	expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*APIKey, error) {
+	var k APIKey
+	var scopes, projects string
+	var expires, used, revoked sql.NullString
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &projects, &k.RotatedFrom, &k.CreatedAt,
+		&expires, &used, &revoked)
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Split(scopes, ",")
+	if projects != "" {
+		k.Projects = strings.Split(projects, ",")
+	}
+	k.ExpiresAt, k.LastUsedAt, k.RevokedAt = expires.String, used.String, revoked.String
+	return &k, nil
+}
+
+// newSecret returns a random key, "vx_" and 48 hex characters
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "vx_" + hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey stores a new key and returns it with its secret. A zero ttl
+// never expires.
+func CreateAPIKey(name string, scopes, projects []string, ttl time.Duration) (*APIKey, string, error) {
+	return insertAPIKey(&APIKey{Name: name, Scopes: scopes, Projects: projects}, ttl)
+}
+
+func insertAPIKey(k *APIKey, ttl time.Duration) (*APIKey, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	var expires, rotated any
+	if ttl > 0 {
+		expires = time.Now().UTC().Add(ttl).Format(SQLiteTime)
+	}
+	if k.RotatedFrom != 0 {
+		rotated = k.RotatedFrom
+	}
+	row := DB.QueryRow(`
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, projects, rotated_from, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+apiKeyColumns+`;`,
+		k.Name, secret[:11], hashSecret(secret), strings.Join(k.Scopes, ","), strings.Join(k.Projects, ","),
+		rotated, expires)
+	k, err = scanAPIKey(row)
+	return k, secret, err
+}
+
+// ListAPIKeys returns every key, revoked ones included
+func ListAPIKeys() ([]APIKey, error) {
+	rows, err := DB.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey reports whether there was an active key to revoke
+func RevokeAPIKey(id int64) (bool, error) {
+	res, err := DB.Exec(`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL;`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RotateAPIKey replaces a key with a new one of the same name, scopes and
+// projects. The old key keeps working for grace, so clients can switch
+// over; a zero grace revokes it at once. Returns sql.ErrNoRows if the key
+// is unknown or revoked.
+func RotateAPIKey(id int64, grace time.Duration) (*APIKey, string, error) {
+	old, err := scanAPIKey(DB.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ? AND revoked_at IS NULL;`, id))
+	if err != nil {
+		return nil, "", err
+	}
+	var ttl time.Duration
+	if old.ExpiresAt != "" {
+		created, _ := time.Parse(time.RFC3339, old.CreatedAt)
+		expires, _ := time.Parse(time.RFC3339, old.ExpiresAt)
+		ttl = expires.Sub(created)
+	}
+	k, secret, err := insertAPIKey(&APIKey{Name: old.Name, Scopes: old.Scopes, Projects: old.Projects, RotatedFrom: old.ID}, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if grace <= 0 {
+		_, err = RevokeAPIKey(old.ID)
+	} else {
+		_, err = DB.Exec(`UPDATE api_keys SET expires_at = MIN(COALESCE(expires_at, ?1), ?1) WHERE id = ?2;`,
+			time.Now().UTC().Add(grace).Format(SQLiteTime), old.ID)
+	}
+	return k, secret, err
+}
+
+// AuthenticateAPIKey returns the active key matching secret and records
+// its use. Returns sql.ErrNoRows for unknown, expired or revoked keys.
+func AuthenticateAPIKey(secret string) (*APIKey, error) {
+	k, err := scanAPIKey(DB.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);`,
+		hashSecret(secret)))
+	if err != nil {
+		return nil, err
+	}
+	_, err = DB.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?;`, k.ID)
+	return k, err
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	useTestDB(t)
+	past := time.Now().UTC().Add(-time.Minute).Format(SQLiteTime)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) string // returns the secret to authenticate with
+		ok    bool
+	}{
+		{"active", func(t *testing.T) string {
+			_, secret, _ := CreateAPIKey("active", []string{ScopeRead}, nil, 0)
+			return secret
+		}, true},
+		{"not yet expired", func(t *testing.T) string {
+			_, secret, _ := CreateAPIKey("ttl", []string{ScopeRead}, nil, time.Hour)
+			return secret
+		}, true},
+		{"expired", func(t *testing.T) string {
+			k, secret, _ := CreateAPIKey("expired", []string{ScopeRead}, nil, time.Hour)
+			DB.Exec(`UPDATE api_keys SET expires_at = ? WHERE id = ?;`, past, k.ID)
+			return secret
+		}, false},
+		{"revoked", func(t *testing.T) string {
+			k, secret, _ := CreateAPIKey("revoked", []string{ScopeRead}, nil, 0)
+			if ok, err := RevokeAPIKey(k.ID); !ok || err != nil {
+				t.Fatalf("RevokeAPIKey = %v, %v", ok, err)
+			}
+			return secret
+		}, false},
+		{"rotated, within grace", func(t *testing.T) string {
+			k, secret, _ := CreateAPIKey("grace", []string{ScopeRead}, nil, 0)
+			if _, _, err := RotateAPIKey(k.ID, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			return secret
+		}, true},
+		{"rotated, grace over", func(t *testing.T) string {
+			k, secret, _ := CreateAPIKey("graceover", []string{ScopeRead}, nil, 0)
+			if _, _, err := RotateAPIKey(k.ID, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			DB.Exec(`UPDATE api_keys SET expires_at = ? WHERE id = ?;`, past, k.ID)
+			return secret
+		}, false},
+		{"rotated without grace", func(t *testing.T) string {
+			k, secret, _ := CreateAPIKey("nograce", []string{ScopeRead}, nil, 0)
+			if _, _, err := RotateAPIKey(k.ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			return secret
+		}, false},
+		{"replacement", func(t *testing.T) string {
+			k, _, _ := CreateAPIKey("replaced", []string{ScopeRead}, []string{"acme"}, 0)
+			nk, secret, err := RotateAPIKey(k.ID, 0)
+			if err != nil || nk.RotatedFrom != k.ID || nk.Name != k.Name || !nk.AllowsProject("acme") || nk.AllowsProject("other") {
+				t.Fatalf("RotateAPIKey = %+v, %v", nk, err)
+			}
+			return secret
+		}, true},
+		{"unknown", func(t *testing.T) string { return "vx_nope" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := tt.setup(t)
+			k, err := AuthenticateAPIKey(secret)
+			if tt.ok && err != nil {
+				t.Fatalf("AuthenticateAPIKey: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("AuthenticateAPIKey = %+v, %v, want sql.ErrNoRows", k, err)
+			}
+		})
+	}
+}
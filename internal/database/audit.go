@@ -0,0 +1,41 @@
+package database
+
+// AuditRecord is one reviewer action on a job
+type AuditRecord struct {
+	ID         int64  `json:"id"`
+	JobID      int64  `json:"job_id"`
+	Action     string `json:"action"`
+	Actor      string `json:"actor"`
+	Detail     string `json:"detail,omitempty"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func InsertAudit(jobID int64, action, actor, detail, remoteAddr string) error {
+	query := `INSERT INTO approval_audit (job_id, action, actor, detail, remote_addr) VALUES (?, ?, ?, ?, ?);`
+	_, err := DB.Exec(query, jobID, action, actor, detail, remoteAddr)
+	return err
+}
+
+func GetAuditByJob(jobID int64) ([]AuditRecord, error) {
+	query := `SELECT id, job_id, action, COALESCE(actor, ''), COALESCE(detail, ''), COALESCE(remote_addr, ''), created_at
+		FROM approval_audit WHERE job_id = ? ORDER BY id;`
+	rows, err := DB.Query(query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []AuditRecord
+	for rows.Next() {
+		var a AuditRecord
+		if err := rows.Scan(&a.ID, &a.JobID, &a.Action, &a.Actor, &a.Detail, &a.RemoteAddr, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import "time"
+
+// Budget periods, in UTC
+const (
+	BudgetDaily   = "daily"
+	BudgetMonthly = "monthly"
+)
+
+// What happens to generations once a budget is used up
+const (
+	BudgetReject   = "reject"   // fail with 429
+	BudgetFallback = "fallback" // route to the local Ollama provider
+)
+
+// Budget caps the LLM usage of one project, or of everything when
+// ProjectName is empty, over a day or a month. Zero limits are unlimited.
+type Budget struct {
+	ID          int64   `json:"id"`
+	ProjectName string  `json:"project_name,omitempty"`
+	Period      string  `json:"period"`
+	MaxTokens   int     `json:"max_tokens"`
+	MaxCostUSD  float64 `json:"max_cost_usd"`
+	Action      string  `json:"action"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+// BudgetState is a budget with the usage of its current period
+type BudgetState struct {
+	Budget
+	UsedTokens  int     `json:"used_tokens"`
+	UsedCostUSD float64 `json:"used_cost_usd"`
+	Exceeded    bool    `json:"exceeded"`
+	ResetsAt    string  `json:"resets_at"`
+}
+
+const budgetColumns = `id, project_name, period, max_tokens, max_cost_usd, action, updated_at`
+
+func scanBudget(row interface{ Scan(...any) error }) (*Budget, error) {
+	var b Budget
+	err := row.Scan(&b.ID, &b.ProjectName, &b.Period, &b.MaxTokens, &b.MaxCostUSD, &b.Action, &b.UpdatedAt)
+	return &b, err
+}
+
+// SetBudget creates or replaces the budget of b's project and period
+func SetBudget(b *Budget) error {
+	return DB.QueryRow(`
+		INSERT INTO budgets (project_name, period, max_tokens, max_cost_usd, action) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (project_name, period) DO UPDATE SET max_tokens = excluded.max_tokens,
+			max_cost_usd = excluded.max_cost_usd, action = excluded.action, updated_at = CURRENT_TIMESTAMP
+		RETURNING id, updated_at;`,
+		b.ProjectName, b.Period, b.MaxTokens, b.MaxCostUSD, b.Action,
+	).Scan(&b.ID, &b.UpdatedAt)
+}
+
+// DeleteBudget reports whether there was a budget to delete
+func DeleteBudget(projectName, period string) (bool, error) {
+	res, err := DB.Exec(`DELETE FROM budgets WHERE project_name = ? AND period = ?;`, projectName, period)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListBudgets returns every budget, the global ones first
+func ListBudgets() ([]Budget, error) {
+	return listBudgets(`SELECT ` + budgetColumns + ` FROM budgets ORDER BY project_name, period;`)
+}
+
+// ProjectBudgets returns the budgets a project's generations count against:
+// the global ones and its own
+func ProjectBudgets(projectName string) ([]Budget, error) {
+	return listBudgets(`SELECT `+budgetColumns+` FROM budgets WHERE project_name IN ('', ?) ORDER BY project_name, period;`, projectName)
+}
+
+func listBudgets(query string, args ...any) ([]Budget, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, *b)
+	}
+	return budgets, rows.Err()
+}
+
+// Window is the current period of the budget: today or this month, UTC
+func (b Budget) Window(now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	if b.Period == BudgetMonthly {
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// State sums the ledger over the budget's current period
+func (b Budget) State(now time.Time) (BudgetState, error) {
+	start, end := b.Window(now)
+	s := BudgetState{Budget: b, ResetsAt: end.Format(time.RFC3339)}
+
+	query := `SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0), ROUND(COALESCE(SUM(cost_usd), 0), 8)
+		FROM llm_usage WHERE created_at >= ? AND created_at < ?`
+	args := []any{start.Format(time.DateTime), end.Format(time.DateTime)}
+	if b.ProjectName != "" {
+		query += ` AND project_name = ?`
+		args = append(args, b.ProjectName)
+	}
+	if err := DB.QueryRow(query, args...).Scan(&s.UsedTokens, &s.UsedCostUSD); err != nil {
+		return s, err
+	}
+	s.Exceeded = (b.MaxTokens > 0 && s.UsedTokens >= b.MaxTokens) ||
+		(b.MaxCostUSD > 0 && s.UsedCostUSD >= b.MaxCostUSD)
+	return s, nil
+}
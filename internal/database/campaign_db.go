@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// Campaign statuses
+const (
+	CampaignRunning   = "running"
+	CampaignCompleted = "completed" // every platform succeeded
+	CampaignPartial   = "partial"
+	CampaignFailed    = "failed"
+)
+
+// Campaign is one set of notes fanned out to several platforms at once
+type Campaign struct {
+	ID          int64             `json:"id"`
+	ProjectName string            `json:"project_name"`
+	RawNotes    string            `json:"raw_notes,omitempty"`
+	Platforms   []string          `json:"platforms"`
+	Status      string            `json:"status"`
+	Failures    map[string]string `json:"failures,omitempty"` // platform -> error
+	CreatedAt   string            `json:"created_at"`
+	CompletedAt string            `json:"completed_at,omitempty"`
+}
+
+// InsertCampaign stores c as running and fills in its ID
+func InsertCampaign(c *Campaign) error {
+	c.Status = CampaignRunning
+	pid, err := projectID(DB, c.ProjectName)
+	if err != nil {
+		return err
+	}
+	return DB.QueryRow(`
+		INSERT INTO campaigns (project_name, project_id, raw_notes, platforms, status) VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at;`,
+		c.ProjectName, pid, c.RawNotes, strings.Join(c.Platforms, ","), c.Status,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+// FinishCampaign records the outcome once every platform is done
+func FinishCampaign(c *Campaign) error {
+	var failures any
+	if len(c.Failures) > 0 {
+		b, _ := json.Marshal(c.Failures)
+		failures = string(b)
+	}
+	return DB.QueryRow(`
+		UPDATE campaigns SET status = ?, failures = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ?
+		RETURNING completed_at;`, c.Status, failures, c.ID,
+	).Scan(&c.CompletedAt)
+}
+
+func GetCampaign(id int64) (*Campaign, error) {
+	var c Campaign
+	var platforms, failures string
+	var completed sql.NullString
+	err := DB.QueryRow(`
+		SELECT id, COALESCE(project_name, ''), COALESCE(raw_notes, ''), COALESCE(platforms, ''), status,
+		       COALESCE(failures, ''), created_at, completed_at
+		FROM campaigns WHERE id = ?;`, id,
+	).Scan(&c.ID, &c.ProjectName, &c.RawNotes, &platforms, &c.Status, &failures, &c.CreatedAt, &completed)
+	if err != nil {
+		return nil, err
+	}
+	c.CompletedAt = completed.String
+	if platforms != "" {
+		c.Platforms = strings.Split(platforms, ",")
+	}
+	if failures != "" {
+		json.Unmarshal([]byte(failures), &c.Failures)
+	}
+	return &c, nil
+}
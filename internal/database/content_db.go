@@ -0,0 +1,207 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Content statuses
+const (
+	ContentGenerated       = "generated"
+	ContentWaitingApproval = "waiting_approval"
+	ContentApproved        = "approved"
+	ContentRejected        = "rejected"
+)
+
+// Content is one generated feed for one platform
+type Content struct {
+	ID          int64           `json:"id"`
+	Platform    string          `json:"platform"`
+	ProjectName string          `json:"project_name"`
+	ProjectID   int64           `json:"project_id,omitempty"`
+	RawNotes    string          `json:"raw_notes,omitempty"`
+	Output      string          `json:"output"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	Provider    string          `json:"provider,omitempty"`
+	Model       string          `json:"model,omitempty"`
+	LatencyMS   int64           `json:"latency_ms"`
+	Status      string          `json:"status"`
+	CreatedAt   string          `json:"created_at"`
+
+	PromptVersion string          `json:"prompt_version,omitempty"`
+	Params        json.RawMessage `json:"params,omitempty"` // llm.Options the feed was generated with
+
+	// Regenerating a feed creates a new row linked to the one it came from.
+	// RootID is the first version; Version counts up from 1 within that family.
+	ParentID int64 `json:"parent_id,omitempty"`
+	RootID   int64 `json:"root_id"`
+	Version  int   `json:"version"`
+
+	CampaignID int64 `json:"campaign_id,omitempty"` // set for feeds made by POST /generate
+
+	// Summed over every LLM call the feed took, repairs included
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// ContentRepository is the typed access layer for the contents table
+type ContentRepository struct {
+	db *sql.DB
+}
+
+// Contents returns a repository bound to the open database
+func Contents() *ContentRepository {
+	return &ContentRepository{db: DB}
+}
+
+const contentColumns = `id, platform, COALESCE(project_name, ''), COALESCE(raw_notes, ''), COALESCE(output, ''),
+	COALESCE(metadata, ''), COALESCE(provider, ''), COALESCE(model, ''), COALESCE(latency_ms, 0),
+	COALESCE(status, 'generated'), created_at, COALESCE(prompt_version, ''), COALESCE(params, ''),
+	COALESCE(parent_id, 0), COALESCE(root_id, id), version, COALESCE(campaign_id, 0),
+	prompt_tokens, completion_tokens, cost_usd, COALESCE(project_id, 0)`
+
+func scanContent(row interface{ Scan(...any) error }) (*Content, error) {
+	var c Content
+	var metadata, params string
+	if err := row.Scan(&c.ID, &c.Platform, &c.ProjectName, &c.RawNotes, &c.Output,
+		&metadata, &c.Provider, &c.Model, &c.LatencyMS, &c.Status, &c.CreatedAt,
+		&c.PromptVersion, &params, &c.ParentID, &c.RootID, &c.Version, &c.CampaignID,
+		&c.PromptTokens, &c.CompletionTokens, &c.CostUSD, &c.ProjectID); err != nil {
+		return nil, err
+	}
+	if metadata != "" {
+		c.Metadata = json.RawMessage(metadata)
+	}
+	if params != "" {
+		c.Params = json.RawMessage(params)
+	}
+	return &c, nil
+}
+
+// Insert stores c and fills in its ID. With ParentID set the row becomes
+// the next version of the parent's family and RootID/Version are filled in too.
+func (r *ContentRepository) Insert(c *Content) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertContent(tx, c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertContent is Insert within the caller's transaction
+func insertContent(tx *sql.Tx, c *Content) error {
+	if c.Status == "" {
+		c.Status = ContentGenerated
+	}
+
+	var parent, campaign any
+	if c.ParentID != 0 {
+		parent = c.ParentID
+	}
+	if c.CampaignID != 0 {
+		campaign = c.CampaignID
+	}
+	query := `
+		INSERT INTO contents (platform, project_name, raw_notes, output, metadata, provider, model, latency_ms, status,
+			prompt_version, params, parent_id, root_id, version, campaign_id,
+			prompt_tokens, completion_tokens, cost_usd, project_id)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12,
+			(SELECT COALESCE(root_id, id) FROM contents WHERE id = ?12),
+			COALESCE((SELECT MAX(version) + 1 FROM contents
+				WHERE COALESCE(root_id, id) = (SELECT COALESCE(root_id, id) FROM contents WHERE id = ?12)), 1),
+			?13, ?14, ?15, ?16, ?17)
+		RETURNING id, COALESCE(root_id, id), version, created_at, COALESCE(project_id, 0);`
+
+	pid, err := projectID(tx, c.ProjectName)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(query, c.Platform, c.ProjectName, c.RawNotes, c.Output, nullJSON(c.Metadata),
+		c.Provider, c.Model, c.LatencyMS, c.Status, c.PromptVersion, nullJSON(c.Params), parent, campaign,
+		c.PromptTokens, c.CompletionTokens, c.CostUSD, pid,
+	).Scan(&c.ID, &c.RootID, &c.Version, &c.CreatedAt, &c.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// The generated text is the feed's first revision
+	kind := RevisionGenerated
+	if c.ParentID != 0 {
+		kind = RevisionRegenerated
+	}
+	_, err = insertRevision(tx, c.ID, kind, "llm:"+c.Provider, c.Output, 0)
+	return err
+}
+
+// nullJSON stores empty JSON as NULL
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// GetByID fetches one feed of the given platform
+func (r *ContentRepository) GetByID(platform string, id int64) (*Content, error) {
+	query := `SELECT ` + contentColumns + ` FROM contents WHERE platform = ? AND id = ?;`
+	return scanContent(r.db.QueryRow(query, platform, id))
+}
+
+// ListByProject returns a project's feeds for one platform, oldest first.
+// The project is matched by slug, so any spelling of its name works.
+func (r *ContentRepository) ListByProject(platform, projectName string) ([]Content, error) {
+	query := `SELECT ` + contentColumns + ` FROM contents
+		WHERE platform = ? AND project_id = (SELECT id FROM projects WHERE slug = ?) ORDER BY id;`
+	return r.list(query, platform, Slugify(projectName))
+}
+
+// ListByProjectID returns a project's feeds on every platform, oldest first
+func (r *ContentRepository) ListByProjectID(projectID int64) ([]Content, error) {
+	query := `SELECT ` + contentColumns + ` FROM contents WHERE project_id = ? ORDER BY id;`
+	return r.list(query, projectID)
+}
+
+// ListByCampaign returns every feed of a campaign, regenerated versions included
+func (r *ContentRepository) ListByCampaign(campaignID int64) ([]Content, error) {
+	query := `SELECT ` + contentColumns + ` FROM contents WHERE campaign_id = ? ORDER BY id;`
+	return r.list(query, campaignID)
+}
+
+// ListToday returns the feeds generated today (UTC) for one platform
+func (r *ContentRepository) ListToday(platform string) ([]Content, error) {
+	query := `SELECT ` + contentColumns + ` FROM contents WHERE platform = ? AND DATE(created_at) = DATE('now') ORDER BY id;`
+	return r.list(query, platform)
+}
+
+// UpdateStatus moves a feed to a new status
+func (r *ContentRepository) UpdateStatus(id int64, status string) error {
+	_, err := r.db.Exec(`UPDATE contents SET status = ? WHERE id = ?;`, status, id)
+	return err
+}
+
+func (r *ContentRepository) list(query string, args ...any) ([]Content, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contents []Content
+	for rows.Next() {
+		c, err := scanContent(rows)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
@@ -2,52 +2,60 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
-	"vexora-studio/internal/database/schema"
 )
 
 var DB *sql.DB
 
+// dbPath is the file behind DB, used for backups before migrating
+var dbPath string
+
+// Init opens the database and applies any pending migrations
 func Init(path string) error {
-	if err := os.Mkdir("data", 0o755); err != nil && !os.IsExist(err) {
+	if err := Open(path); err != nil {
 		return err
 	}
 
-	db, err := sql.Open("sqlite3", path)
+	n, err := MigrateUp()
 	if err != nil {
 		return err
 	}
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
-	db.SetConnMaxLifetime(20 * time.Minute)
-
-	if err := db.Ping(); err != nil {
-		log.Println("Database ping failed, %w", err)
-		return err
+	if n > 0 {
+		log.Printf("🗄️ Database schema upgraded (%d migrations)", n)
 	}
-	DB = db
-
-	// creating the tables in db
+	return nil
+}
 
-	if _, err := DB.Exec(schema.InstagramFeedDBSchema); err != nil {
+// Open connects to the database without touching its schema. Foreign keys
+// are enforced on every connection.
+func Open(path string) error {
+	if err := os.Mkdir("data", 0o755); err != nil && !os.IsExist(err) {
 		return err
 	}
 
-	if _, err := DB.Exec(schema.NewsletterDBSchema); err != nil {
-		return err
+	dsn := path + "?_foreign_keys=on"
+	if strings.Contains(path, "?") {
+		dsn = path + "&_foreign_keys=on"
 	}
-
-	if _, err := DB.Exec(schema.LinkedinFeedDBSchema); err != nil {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
 		return err
 	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(20 * time.Minute)
 
-	if _, err := DB.Exec(schema.TwitterFeedDBSchema); err != nil {
+	if err := db.Ping(); err != nil {
+		log.Printf("Database ping failed, %v", err)
 		return err
 	}
+	DB = db
+	dbPath = path
 	return nil
-
 }
 
 func Close() error {
@@ -60,3 +68,59 @@ func Close() error {
 func GetDB() *sql.DB {
 	return DB
 }
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func tableExists(q querier, table string) (bool, error) {
+	var n int
+	err := q.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;", table).Scan(&n)
+	return n > 0, err
+}
+
+func columnExists(q querier, table, column string) (bool, error) {
+	rows, err := q.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid              int
+			name, ctype      string
+			notNull, primary int
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &primary); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// ensureColumn adds a column to an existing table if it is missing
+func ensureColumn(q querier, table, column, decl string) error {
+	exists, err := columnExists(q, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = q.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, decl))
+	return err
+}
+
+// envDisabled reports whether an on-by-default switch was turned off ("0", "false", "off")
+func envDisabled(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "0", "false", "off", "no":
+		return true
+	}
+	return false
+}
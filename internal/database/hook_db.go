@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Hook delivery outcomes
+const (
+	HookQueued    = "queued"    // jobs were enqueued
+	HookIgnored   = "ignored"   // nothing to post about
+	HookUnmapped  = "unmapped"  // no project has the repository
+	HookDuplicate = "duplicate" // redelivery of a logged ID or digest, not logged again
+)
+
+// ErrDuplicateHookDelivery means the delivery was already logged
+var ErrDuplicateHookDelivery = errors.New("hook delivery already received")
+
+// HookDelivery is one webhook received from a git forge
+type HookDelivery struct {
+	ID         int64   `json:"id"`
+	Source     string  `json:"source"`
+	DeliveryID string  `json:"delivery_id,omitempty"`
+	Digest     string  `json:"-"` // middleware.Signature.ReplayKey of the request
+	Event      string  `json:"event,omitempty"`
+	Repo       string  `json:"repo,omitempty"`
+	ProjectID  int64   `json:"project_id,omitempty"`
+	Status     string  `json:"status"`
+	Detail     string  `json:"detail,omitempty"`
+	JobIDs     []int64 `json:"job_ids,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// HookJobs are the jobs a delivery queues, one per platform
+type HookJobs struct {
+	Project   string
+	Notes     string
+	Platforms []string
+}
+
+// InsertHookDelivery logs d and queues jobs (if any) in one transaction,
+// filling in d's ID and JobIDs. The row goes in first, so a delivery its
+// UNIQUE constraint already knows fails with ErrDuplicateHookDelivery and
+// queues nothing.
+func InsertHookDelivery(d *HookDelivery, jobs *HookJobs) error {
+	var deliveryID, digest, projectID any
+	if d.DeliveryID != "" {
+		deliveryID = d.DeliveryID
+	}
+	if d.Digest != "" {
+		digest = d.Digest
+	}
+	if d.ProjectID != 0 {
+		projectID = d.ProjectID
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO hook_deliveries (source, delivery_id, digest, event, repo, project_id, status, detail)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at;`,
+		d.Source, deliveryID, digest, d.Event, d.Repo, projectID, d.Status, d.Detail,
+	).Scan(&d.ID, &d.CreatedAt)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicateHookDelivery
+	}
+	if err != nil {
+		return err
+	}
+
+	if jobs != nil {
+		ids := make([]string, 0, len(jobs.Platforms))
+		for _, platform := range jobs.Platforms {
+			id, err := enqueueJob(tx, jobs.Project, platform, jobs.Notes, "", 0)
+			if err != nil {
+				return err
+			}
+			d.JobIDs = append(d.JobIDs, id)
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		if _, err := tx.Exec(`UPDATE hook_deliveries SET job_ids = ? WHERE id = ?;`, strings.Join(ids, ","), d.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,301 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Migration is one numbered schema change. Up/Down SQL is checksummed so an
+// applied migration can't be edited behind the database's back; add a new
+// migration instead. UpFunc/DownFunc run after the SQL, in the same
+// transaction, for steps that need to inspect the existing schema.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   func(tx *sql.Tx) error
+	DownFunc func(tx *sql.Tx) error
+}
+
+// Checksum identifies the SQL of a migration
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Name + "\x00" + m.Up + "\x00" + m.Down))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m Migration) reversible() bool {
+	return m.Down != "" || m.DownFunc != nil
+}
+
+// MigrationState is a migration as seen by `vexora migrate status`
+type MigrationState struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+	Mismatch  bool // applied with different SQL than this build has
+	Unknown   bool // applied by a newer build, not in this one
+}
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration does not match this build")
+	ErrUnknownMigration = errors.New("database has migrations this build does not know")
+	ErrIrreversible     = errors.New("migration cannot be rolled back")
+)
+
+const schemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+type appliedMigration struct {
+	name, checksum, appliedAt string
+}
+
+func appliedMigrations() (map[int]appliedMigration, error) {
+	if _, err := DB.Exec(schemaMigrationsTable); err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`SELECT version, name, checksum, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var v int
+		var a appliedMigration
+		if err := rows.Scan(&v, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[v] = a
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists every known migration plus any applied ones this build doesn't know
+func MigrationStatus() ([]MigrationState, error) {
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	known := map[int]bool{}
+	for _, m := range migrations {
+		known[m.Version] = true
+		s := MigrationState{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.appliedAt
+			s.Mismatch = a.checksum != m.Checksum()
+		}
+		states = append(states, s)
+	}
+	for v, a := range applied {
+		if !known[v] {
+			states = append(states, MigrationState{Version: v, Name: a.name, Applied: true, AppliedAt: a.appliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// validate refuses to touch a database whose history disagrees with this build
+func validate(states []MigrationState) error {
+	for _, s := range states {
+		if s.Mismatch {
+			return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, s.Version, s.Name)
+		}
+		if s.Unknown {
+			return fmt.Errorf("%w: %03d_%s", ErrUnknownMigration, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// MigrateUp applies every pending migration in order and returns how many ran.
+// A database that already holds data is backed up first.
+func MigrateUp() (int, error) {
+	states, err := MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+	if err := validate(states); err != nil {
+		return 0, err
+	}
+
+	done := map[int]bool{}
+	for _, s := range states {
+		done[s.Version] = s.Applied
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	if err := backup(); err != nil {
+		return 0, fmt.Errorf("backup before migrating: %w", err)
+	}
+	if err := deferForeignKeys(); err != nil {
+		return 0, err
+	}
+	defer enforceForeignKeys()
+
+	for i, m := range pending {
+		if err := runMigration(m, true); err != nil {
+			return i, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		log.Printf("⬆️ Applied migration %03d_%s", m.Version, m.Name)
+	}
+	return len(pending), nil
+}
+
+// MigrateDown rolls back the last steps applied migrations, newest first
+func MigrateDown(steps int) (int, error) {
+	states, err := MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+	if err := validate(states); err != nil {
+		return 0, err
+	}
+
+	byVersion := map[int]Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+	var targets []Migration
+	for i := len(states) - 1; i >= 0 && len(targets) < steps; i-- {
+		if states[i].Applied {
+			targets = append(targets, byVersion[states[i].Version])
+		}
+	}
+	if len(targets) == 0 {
+		return 0, nil
+	}
+
+	if err := backup(); err != nil {
+		return 0, fmt.Errorf("backup before rolling back: %w", err)
+	}
+	if err := deferForeignKeys(); err != nil {
+		return 0, err
+	}
+	defer enforceForeignKeys()
+
+	for i, m := range targets {
+		if !m.reversible() {
+			return i, fmt.Errorf("%w: %03d_%s", ErrIrreversible, m.Version, m.Name)
+		}
+		if err := runMigration(m, false); err != nil {
+			return i, fmt.Errorf("rollback %03d_%s: %w", m.Version, m.Name, err)
+		}
+		log.Printf("⬇️ Rolled back migration %03d_%s", m.Version, m.Name)
+	}
+	return len(targets), nil
+}
+
+// runMigration applies (or reverts) one migration and records it, atomically
+func runMigration(m Migration, up bool) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, fn := m.Up, m.UpFunc
+	if !up {
+		query, fn = m.Down, m.DownFunc
+	}
+	if query != "" {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	if fn != nil {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := checkForeignKeys(tx); err != nil {
+		return err
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?);`, m.Version, m.Name, m.Checksum())
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = ?;`, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deferForeignKeys turns foreign key enforcement off for the migrations,
+// which rebuild and drop referenced tables; checkForeignKeys verifies each
+// one left no dangling reference behind. The pragma can't change inside a
+// transaction, and DB has a single connection, so it holds for the run.
+func deferForeignKeys() error {
+	_, err := DB.Exec(`PRAGMA foreign_keys = OFF;`)
+	return err
+}
+
+func enforceForeignKeys() {
+	if _, err := DB.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
+		log.Printf("❌ Failed to re-enable foreign keys: %v", err)
+	}
+}
+
+func checkForeignKeys(tx *sql.Tx) error {
+	var table, parent string
+	var rowid sql.NullInt64
+	var fkid int
+	err := tx.QueryRow(`PRAGMA foreign_key_check;`).Scan(&table, &rowid, &parent, &fkid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("row %d of %s references a missing %s row", rowid.Int64, table, parent)
+}
+
+// backup copies the database next to itself (e.g. vexora.db.bak-20240101-150405)
+// unless it is brand new. Disable with VEXORA_DB_BACKUP=0.
+func backup() error {
+	if dbPath == "" || dbPath == ":memory:" || envDisabled("VEXORA_DB_BACKUP") {
+		return nil
+	}
+	var tables int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence');`).Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	dest := fmt.Sprintf("%s.bak-%s", dbPath, time.Now().Format("20060102-150405"))
+	if _, err := DB.Exec(`VACUUM INTO ?;`, dest); err != nil {
+		return err
+	}
+	log.Printf("💾 Backed up database to %s", dest)
+	return nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"vexora-studio/internal/database/schema"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// useTestDB points DB at a freshly migrated database in a temp dir
+func useTestDB(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir()) // Open creates ./data
+	t.Setenv("VEXORA_DB_BACKUP", "0")
+	prev, prevPath := DB, dbPath
+	if err := Init(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DB.Close()
+		DB, dbPath = prev, prevPath
+	})
+}
+
+// schemaSQL lists the schema's tables and indexes, without the migration log
+func schemaSQL(t *testing.T) []string {
+	t.Helper()
+	rows, err := DB.Query(`SELECT type || ' ' || name || ': ' || COALESCE(sql, '') FROM sqlite_master
+		WHERE name NOT IN ('schema_migrations', 'sqlite_sequence') ORDER BY type, name;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		rows.Scan(&s)
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	useTestDB(t)
+	want := schemaSQL(t)
+	if len(want) == 0 {
+		t.Fatal("no schema after migrating up")
+	}
+
+	n, err := MigrateDown(len(migrations))
+	if err != nil || n != len(migrations) {
+		t.Fatalf("MigrateDown = %d, %v, want %d", n, err, len(migrations))
+	}
+	// Rolling back 002 puts back the per-platform tables it adopted
+	var legacy []string
+	for _, l := range schema.LegacyFeedTables {
+		legacy = append(legacy, l[0])
+	}
+	var left []string
+	for _, s := range schemaSQL(t) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(s, "table "), ":")
+		if !strings.HasPrefix(s, "table ") || !slices.Contains(legacy, name) {
+			left = append(left, s)
+		}
+	}
+	if len(left) != 0 {
+		t.Errorf("left after rolling everything back:\n%s", strings.Join(left, "\n"))
+	}
+	states, err := MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		if s.Applied {
+			t.Errorf("%03d_%s still applied", s.Version, s.Name)
+		}
+	}
+
+	n, err = MigrateUp()
+	if err != nil || n != len(migrations) {
+		t.Fatalf("MigrateUp = %d, %v, want %d", n, err, len(migrations))
+	}
+	if got := schemaSQL(t); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("schema after the round trip differs:\ngot:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n, err := MigrateUp(); n != 0 || err != nil {
+		t.Errorf("second MigrateUp = %d, %v, want nothing to do", n, err)
+	}
+
+	var fk int
+	DB.QueryRow(`PRAGMA foreign_keys;`).Scan(&fk)
+	if fk != 1 {
+		t.Error("foreign keys not enforced after migrating")
+	}
+}
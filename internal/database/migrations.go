@@ -0,0 +1,416 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"vexora-studio/internal/database/schema"
+)
+
+// migrations is the full schema history, oldest first. Never edit or
+// renumber an entry once it has shipped; append a new one.
+//
+// 001-005 also adopt databases from before the runner existed: the old
+// one-table-per-platform feeds (data/vexora.db) and the original
+// journal_entries with drafts in columns (vexora_back.db).
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_contents",
+		Up:      schema.ContentsDBSchema,
+		Down:    `DROP TABLE IF EXISTS contents;`,
+	},
+	{
+		Version: 2,
+		Name:    "import_legacy_feed_tables",
+		Down:    schema.RestoreLegacyFeedTables,
+		UpFunc:  importLegacyFeeds,
+	},
+	{
+		Version:  3,
+		Name:     "create_job_queue",
+		Up:       schema.JournalEntriesDBSchema,
+		Down:     `DROP TABLE IF EXISTS journal_entries;`,
+		UpFunc:   upgradeJobQueue,
+		DownFunc: restoreLegacyJournal,
+	},
+	{
+		Version: 4,
+		Name:    "import_legacy_journal_drafts",
+		Down:    `DELETE FROM contents WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') = 'journal_entries';`,
+		UpFunc:  importLegacyJournal,
+	},
+	{
+		Version: 5,
+		Name:    "create_approval_audit",
+		Up:      schema.ApprovalAuditDBSchema,
+		Down:    `DROP TABLE IF EXISTS approval_audit;`,
+	},
+	{
+		Version: 6,
+		Name:    "add_generation_metadata",
+		Up: `
+			ALTER TABLE contents ADD COLUMN prompt_version TEXT;
+			ALTER TABLE contents ADD COLUMN params TEXT;
+			ALTER TABLE contents ADD COLUMN parent_id INTEGER REFERENCES contents(id);
+			ALTER TABLE contents ADD COLUMN root_id INTEGER REFERENCES contents(id);
+			ALTER TABLE contents ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+			CREATE INDEX IF NOT EXISTS idx_contents_root ON contents (root_id);`,
+		Down: `
+			DROP INDEX IF EXISTS idx_contents_root;
+			ALTER TABLE contents DROP COLUMN version;
+			ALTER TABLE contents DROP COLUMN root_id;
+			ALTER TABLE contents DROP COLUMN parent_id;
+			ALTER TABLE contents DROP COLUMN params;
+			ALTER TABLE contents DROP COLUMN prompt_version;`,
+	},
+	{
+		Version: 7,
+		Name:    "create_content_revisions",
+		Up:      schema.ContentRevisionsDBSchema,
+		Down:    `DROP TABLE IF EXISTS content_revisions;`,
+	},
+	{
+		Version: 8,
+		Name:    "create_prompt_templates",
+		Up:      schema.PromptTemplatesDBSchema,
+		Down:    `DROP TABLE IF EXISTS prompt_templates;`,
+	},
+	{
+		Version: 9,
+		Name:    "create_voice_profiles",
+		Up:      schema.VoiceProfilesDBSchema,
+		Down:    `DROP TABLE IF EXISTS project_voices; DROP TABLE IF EXISTS voice_profiles;`,
+	},
+	{
+		Version: 10,
+		Name:    "create_campaigns",
+		Up:      schema.CampaignsDBSchema,
+		Down: `
+			DROP INDEX IF EXISTS idx_contents_campaign;
+			ALTER TABLE contents DROP COLUMN campaign_id;
+			DROP TABLE IF EXISTS campaigns;`,
+	},
+	{
+		Version: 11,
+		Name:    "create_llm_usage",
+		Up:      schema.LLMUsageDBSchema,
+		Down: `
+			ALTER TABLE contents DROP COLUMN cost_usd;
+			ALTER TABLE contents DROP COLUMN completion_tokens;
+			ALTER TABLE contents DROP COLUMN prompt_tokens;
+			DROP INDEX IF EXISTS idx_llm_usage_created;
+			DROP TABLE IF EXISTS llm_usage;`,
+	},
+	{
+		Version: 12,
+		Name:    "create_budgets",
+		Up:      schema.BudgetsDBSchema,
+		Down: `
+			DROP INDEX IF EXISTS idx_llm_usage_project;
+			DROP TABLE IF EXISTS budgets;`,
+	},
+	{
+		Version: 13,
+		Name:    "create_projects",
+		Up:      schema.ProjectsDBSchema,
+		Down:    schema.RestoreProjectVoices,
+		UpFunc:  backfillProjects,
+	},
+	{
+		Version: 14,
+		Name:    "create_api_keys",
+		Up:      schema.APIKeysDBSchema,
+		Down:    `DROP TABLE IF EXISTS api_keys;`,
+	},
+	{
+		Version: 15,
+		Name:    "create_hook_deliveries",
+		Up:      schema.HookDeliveriesDBSchema,
+		Down:    `DROP TABLE IF EXISTS hook_deliveries;`,
+	},
+	{
+		Version: 16,
+		Name:    "create_webhooks",
+		Up:      schema.WebhooksDBSchema,
+		Down:    `DROP TABLE IF EXISTS webhook_deliveries; DROP TABLE IF EXISTS webhooks;`,
+	},
+	{
+		Version: 17,
+		Name:    "add_llm_usage_status",
+		Up: `
+			ALTER TABLE llm_usage ADD COLUMN status TEXT NOT NULL DEFAULT 'ok';
+			ALTER TABLE llm_usage ADD COLUMN error TEXT;`,
+		Down: `
+			ALTER TABLE llm_usage DROP COLUMN error;
+			ALTER TABLE llm_usage DROP COLUMN status;`,
+	},
+	{
+		Version: 18,
+		Name:    "add_project_delete_rules",
+		Up:      schema.ProjectDeleteRules,
+		Down:    schema.RestoreProjectDeleteRules,
+	},
+	{
+		Version: 19,
+		Name:    "add_hook_delivery_digest",
+		Up: `
+			ALTER TABLE hook_deliveries ADD COLUMN digest TEXT;
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_hook_deliveries_digest ON hook_deliveries (source, digest);`,
+		Down: `
+			DROP INDEX IF EXISTS idx_hook_deliveries_digest;
+			ALTER TABLE hook_deliveries DROP COLUMN digest;`,
+	},
+}
+
+// importLegacyFeeds moves rows from the old per-platform tables into
+// contents and drops those tables. The original IDs are kept in metadata
+// so the down migration can rebuild them.
+func importLegacyFeeds(tx *sql.Tx) error {
+	for _, legacy := range schema.LegacyFeedTables {
+		table, platform := legacy[0], legacy[1]
+		exists, err := tableExists(tx, table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		// the provider column only exists on tables created after it was added
+		provider := "NULL"
+		if ok, err := columnExists(tx, table, "provider"); err != nil {
+			return err
+		} else if ok {
+			provider = "provider"
+		}
+
+		res, err := tx.Exec(fmt.Sprintf(`
+			INSERT INTO contents (platform, project_name, output, metadata, provider, created_at)
+			SELECT ?, project_name, feed, json_object('imported_from', '%[2]s', 'legacy_id', id), %[1]s, created_at
+			FROM %[2]s ORDER BY id;`, provider, table), platform)
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", table, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("DROP TABLE %s;", table)); err != nil {
+			return fmt.Errorf("dropping %s: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		log.Printf("📦 Imported %d rows from %s into contents", n, table)
+	}
+	return nil
+}
+
+// legacyJournalTable holds the pre-queue journal_entries once it has been moved aside
+const legacyJournalTable = "journal_entries_v1"
+
+// upgradeJobQueue runs after CREATE TABLE IF NOT EXISTS journal_entries.
+// The original journal_entries (drafts in columns) is renamed to
+// journal_entries_v1 and replaced by a fresh queue; databases from the
+// first queue releases only get their missing columns.
+func upgradeJobQueue(tx *sql.Tx) error {
+	legacy, err := columnExists(tx, "journal_entries", "polished_note")
+	if err != nil {
+		return err
+	}
+	if legacy {
+		if _, err := tx.Exec(`ALTER TABLE journal_entries RENAME TO ` + legacyJournalTable + `;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(schema.JournalEntriesDBSchema); err != nil {
+			return err
+		}
+		log.Printf("📦 Moved the original journal_entries to %s", legacyJournalTable)
+		return nil
+	}
+
+	for _, col := range queueColumns {
+		if err := ensureColumn(tx, "journal_entries", col[0], col[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueColumns are the columns added to journal_entries while it grew into
+// a job queue. ALTER TABLE can't use non-constant defaults, hence no CURRENT_TIMESTAMP.
+var queueColumns = [][2]string{
+	{"feed_type", "TEXT"},
+	{"status", "TEXT"},
+	{"priority", "TEXT DEFAULT 'NORMAL'"},
+	{"attempt_count", "INTEGER DEFAULT 0"},
+	{"max_attempts", "INTEGER DEFAULT 3"},
+	{"next_attempt_at", "DATETIME"},
+	{"generated_subject", "TEXT"},
+	{"generated_content", "TEXT"},
+	{"generated_tags", "TEXT"},
+	{"provider", "TEXT"},
+	{"approval_token", "TEXT"},
+	{"approval_expires_at", "DATETIME"},
+	{"last_notification_sent", "DATETIME"},
+	{"error_msg", "TEXT"},
+	{"updated_at", "DATETIME"},
+	{"content_id", "INTEGER"},
+}
+
+// restoreLegacyJournal undoes the rename done by upgradeJobQueue
+func restoreLegacyJournal(tx *sql.Tx) error {
+	exists, err := tableExists(tx, legacyJournalTable)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE ` + legacyJournalTable + ` RENAME TO journal_entries;`)
+	return err
+}
+
+// importLegacyJournal turns the drafts stored in journal_entries_v1 columns
+// into contents rows, one per platform. processing_time becomes latency_ms.
+func importLegacyJournal(tx *sql.Tx) error {
+	exists, err := tableExists(tx, legacyJournalTable)
+	if err != nil || !exists {
+		return err
+	}
+
+	// Columns were added over time; select NULL for any this copy never got
+	cols := []string{"twitter_draft", "linkedin_draft", "instagram_caption", "processing_time", "newsletter_subject", "newsletter_body"}
+	exprs := make([]string, len(cols))
+	for i, col := range cols {
+		exprs[i] = "NULL"
+		if ok, err := columnExists(tx, legacyJournalTable, col); err != nil {
+			return err
+		} else if ok {
+			exprs[i] = col
+		}
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT id, COALESCE(project_name, ''), COALESCE(raw_notes, ''), COALESCE(tags, ''), COALESCE(CAST(created_at AS TEXT), ''),
+		       COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(%s, '')
+		FROM %s ORDER BY id;`, exprs[0], exprs[1], exprs[2], exprs[3], exprs[4], exprs[5], legacyJournalTable))
+	if err != nil {
+		return err
+	}
+
+	// Read everything first: the connection is busy until rows is closed
+	type entry struct {
+		id                                                    int64
+		project, notes, tags, created                         string
+		twitter, linkedin, instagram, took, nlSubject, nlBody string
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.project, &e.notes, &e.tags, &e.created,
+			&e.twitter, &e.linkedin, &e.instagram, &e.took, &e.nlSubject, &e.nlBody); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	imported := 0
+	for _, e := range entries {
+		var latency int64
+		if d, err := time.ParseDuration(e.took); err == nil {
+			latency = d.Milliseconds()
+		}
+		meta := map[string]any{"imported_from": "journal_entries", "journal_id": e.id}
+
+		feeds := [][2]string{{"twitter", e.twitter}, {"linkedin", e.linkedin}, {"instagram", e.instagram}}
+		if strings.TrimSpace(e.nlBody) != "" {
+			var tags []string
+			for _, t := range strings.Split(e.tags, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					tags = append(tags, t)
+				}
+			}
+			nl, _ := json.Marshal(map[string]any{"subject_line": e.nlSubject, "preview_text": "", "tags": tags, "body": e.nlBody})
+			feeds = append(feeds, [2]string{"newsletter", string(nl)})
+		}
+
+		for _, f := range feeds {
+			if strings.TrimSpace(f[1]) == "" {
+				continue
+			}
+			metadata, _ := json.Marshal(meta)
+			if _, err := tx.Exec(`
+				INSERT INTO contents (platform, project_name, raw_notes, output, metadata, latency_ms, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?);`,
+				f[0], e.project, e.notes, f[1], string(metadata), latency, e.created); err != nil {
+				return err
+			}
+			imported++
+		}
+	}
+	log.Printf("📦 Imported %d drafts from %s into contents", imported, legacyJournalTable)
+	return nil
+}
+
+// projectKeyedTables name projects by a plain string key, which becomes the slug
+var projectKeyedTables = []string{"prompt_templates", "budgets", "llm_usage"}
+
+// backfillProjects creates a project for every project name in use, links
+// feeds, jobs and campaigns to it and moves project_voices onto it. Names
+// that slugify alike ("Temp", "temp") share a project.
+func backfillProjects(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		SELECT project_name FROM contents UNION SELECT project_name FROM journal_entries
+		UNION SELECT project_name FROM campaigns UNION SELECT project_name FROM project_voices
+		UNION SELECT project_name FROM prompt_templates UNION SELECT project_name FROM budgets
+		UNION SELECT project_name FROM llm_usage;`)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		if name.String != "" {
+			names = append(names, name.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		slug := Slugify(name)
+		if slug == "" {
+			log.Printf("⚠️ Project %q has no usable slug, leaving its rows unlinked", name)
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO projects (slug, name) VALUES (?, ?);`, slug, name); err != nil {
+			return err
+		}
+		for _, table := range []string{"contents", "journal_entries", "campaigns"} {
+			if _, err := tx.Exec(`UPDATE `+table+` SET project_id = (SELECT id FROM projects WHERE slug = ?) WHERE project_name = ?;`, slug, name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE projects SET voice_id = (SELECT voice_id FROM project_voices WHERE project_name = ?)
+			WHERE slug = ? AND voice_id IS NULL;`, name, slug); err != nil {
+			return err
+		}
+		for _, table := range projectKeyedTables {
+			if _, err := tx.Exec(`UPDATE OR IGNORE `+table+` SET project_name = ? WHERE project_name = ?;`, slug, name); err != nil {
+				return err
+			}
+		}
+	}
+	if len(names) > 0 {
+		log.Printf("📦 Linked %d project names to projects", len(names))
+	}
+	_, err = tx.Exec(`DROP TABLE project_voices;`)
+	return err
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrProjectExists  = errors.New("project already exists")
+	ErrProjectInUse   = errors.New("project has feeds, jobs or campaigns")
+	ErrUnknownProject = errors.New("unknown project")
+)
+
+// Project groups feeds and carries the defaults their generation starts from
+type Project struct {
+	ID               int64    `json:"id"`
+	Slug             string   `json:"slug"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	VoiceProfile     string   `json:"voice_profile,omitempty"`     // default voice profile, by name
+	DefaultPlatforms []string `json:"default_platforms,omitempty"` // POST /generate without platforms
+	Hashtags         []string `json:"hashtags,omitempty"`          // used when a request sends none
+	RepoURL          string   `json:"repo_url,omitempty"`
+	Archived         bool     `json:"archived"`
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
+}
+
+// Slugify turns a project name into its URL key: lowercase letters and
+// digits separated by single dashes, at most 64 characters
+func Slugify(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	slug := []rune(sb.String())
+	if len(slug) > 64 {
+		slug = slug[:64]
+	}
+	return strings.TrimRight(string(slug), "-")
+}
+
+const projectColumns = `p.id, p.slug, p.name, COALESCE(p.description, ''), COALESCE(v.name, ''),
+	COALESCE(p.default_platforms, ''), COALESCE(p.hashtags, ''), COALESCE(p.repo_url, ''), p.archived,
+	p.created_at, p.updated_at`
+
+const projectFrom = ` FROM projects p LEFT JOIN voice_profiles v ON v.id = p.voice_id`
+
+func scanProject(row interface{ Scan(...any) error }) (*Project, error) {
+	var p Project
+	var platforms, hashtags string
+	err := row.Scan(&p.ID, &p.Slug, &p.Name, &p.Description, &p.VoiceProfile,
+		&platforms, &hashtags, &p.RepoURL, &p.Archived, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if platforms != "" {
+		p.DefaultPlatforms = strings.Split(platforms, ",")
+	}
+	if hashtags != "" {
+		json.Unmarshal([]byte(hashtags), &p.Hashtags)
+	}
+	return &p, nil
+}
+
+// projectFields are the settings columns of p, voice resolved to its ID
+// (sql.ErrNoRows for an unknown profile)
+func projectFields(p *Project) (voiceID any, platforms, hashtags any, err error) {
+	if p.VoiceProfile != "" {
+		v, err := GetVoice(p.VoiceProfile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		voiceID = v.ID
+	}
+	if len(p.DefaultPlatforms) > 0 {
+		platforms = strings.Join(p.DefaultPlatforms, ",")
+	}
+	if len(p.Hashtags) > 0 {
+		b, _ := json.Marshal(p.Hashtags)
+		hashtags = string(b)
+	}
+	return voiceID, platforms, hashtags, nil
+}
+
+// InsertProject stores p and fills in its ID. Returns ErrProjectExists if
+// the slug is taken.
+func InsertProject(p *Project) error {
+	voiceID, platforms, hashtags, err := projectFields(p)
+	if err != nil {
+		return err
+	}
+	err = DB.QueryRow(`
+		INSERT INTO projects (slug, name, description, voice_id, default_platforms, hashtags, repo_url, archived)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at;`,
+		p.Slug, p.Name, p.Description, voiceID, platforms, hashtags, p.RepoURL, p.Archived,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrProjectExists
+	}
+	return err
+}
+
+// UpdateProject replaces the settings of the project with p's slug
+func UpdateProject(p *Project) error {
+	voiceID, platforms, hashtags, err := projectFields(p)
+	if err != nil {
+		return err
+	}
+	return DB.QueryRow(`
+		UPDATE projects SET name = ?, description = ?, voice_id = ?, default_platforms = ?, hashtags = ?,
+			repo_url = ?, archived = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE slug = ?
+		RETURNING id, created_at, updated_at;`,
+		p.Name, p.Description, voiceID, platforms, hashtags, p.RepoURL, p.Archived, p.Slug,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func GetProject(slug string) (*Project, error) {
+	return scanProject(DB.QueryRow(`SELECT `+projectColumns+projectFrom+` WHERE p.slug = ?;`, slug))
+}
+
+// ListProjects returns the projects by slug, archived ones only if asked
+func ListProjects(includeArchived bool) ([]Project, error) {
+	query := `SELECT ` + projectColumns + projectFrom
+	if !includeArchived {
+		query += ` WHERE p.archived = 0`
+	}
+	rows, err := DB.Query(query + ` ORDER BY p.slug;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+	return projects, rows.Err()
+}
+
+// DeleteProject removes a project no feed, job or campaign refers to yet;
+// archive the others. Its webhooks, budgets and prompt overrides go with
+// it. Reports whether there was a project to delete.
+func DeleteProject(slug string) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var used bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM contents WHERE project_id = p.id)
+			OR EXISTS (SELECT 1 FROM journal_entries WHERE project_id = p.id)
+			OR EXISTS (SELECT 1 FROM campaigns WHERE project_id = p.id)
+		FROM projects p WHERE p.slug = ?;`, slug).Scan(&used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if used {
+		return true, ErrProjectInUse
+	}
+	for _, query := range []string{
+		`DELETE FROM budgets WHERE project_name = ?;`,
+		`DELETE FROM prompt_templates WHERE project_name = ?;`,
+		`DELETE FROM projects WHERE slug = ?;`, // webhooks cascade
+	} {
+		if _, err := tx.Exec(query, slug); err != nil {
+			return true, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// ProjectByRepoURL returns the active project whose repo_url is any of
+// urls (sql.ErrNoRows if none). Web, HTTPS clone and SSH URLs of the same
+// repository all match.
+func ProjectByRepoURL(urls []string) (*Project, error) {
+	projects, err := ListProjects(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.RepoURL == "" {
+			continue
+		}
+		for _, u := range urls {
+			if u != "" && normalizeRepoURL(u) == normalizeRepoURL(p.RepoURL) {
+				return &p, nil
+			}
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// normalizeRepoURL reduces a repository URL to host/path:
+// git@github.com:acme/x.git and https://github.com/acme/x/ are the same
+func normalizeRepoURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	if _, rest, ok := strings.Cut(u, "://"); ok {
+		u = rest
+	} else if user, rest, ok := strings.Cut(u, "@"); ok && !strings.Contains(user, "/") {
+		u = strings.Replace(rest, ":", "/", 1) // scp-like ssh syntax
+	}
+	if _, rest, ok := strings.Cut(u, "@"); ok {
+		u = rest // credentials or ssh user
+	}
+	u = strings.TrimSuffix(strings.TrimRight(u, "/"), ".git")
+	return u
+}
+
+// projectID is the project_id to store for a row naming project name: nil
+// without a usable name, ErrUnknownProject if there is no such project.
+// Projects are only created through POST /projects.
+func projectID(q querier, name string) (any, error) {
+	slug := Slugify(name)
+	if slug == "" {
+		return nil, nil
+	}
+	var id int64
+	err := q.QueryRow(`SELECT id FROM projects WHERE slug = ?;`, slug).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProject, slug)
+	}
+	return id, err
+}
@@ -0,0 +1,74 @@
+package database
+
+// PromptTemplate is one stored version of a prompt template
+type PromptTemplate struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	ProjectName string `json:"project_name,omitempty"` // empty for the global template
+	Version     int    `json:"version"`
+	Body        string `json:"body"`
+	Author      string `json:"author,omitempty"`
+	Archived    bool   `json:"archived"`
+	CreatedAt   string `json:"created_at"`
+}
+
+const promptColumns = `id, name, project_name, version, body, COALESCE(author, ''), archived, created_at`
+
+func scanPrompt(row interface{ Scan(...any) error }) (*PromptTemplate, error) {
+	var p PromptTemplate
+	if err := row.Scan(&p.ID, &p.Name, &p.ProjectName, &p.Version, &p.Body, &p.Author, &p.Archived, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// InsertPromptVersion stores body as the next version of name for projectName
+func InsertPromptVersion(name, projectName, body, author string) (*PromptTemplate, error) {
+	return scanPrompt(DB.QueryRow(`
+		INSERT INTO prompt_templates (name, project_name, version, body, author)
+		SELECT ?1, ?2, COALESCE(MAX(version), 0) + 1, ?3, ?4
+		FROM prompt_templates WHERE name = ?1 AND project_name = ?2
+		RETURNING `+promptColumns+`;`, name, projectName, body, author))
+}
+
+// LatestPrompt returns the newest version of name that has not been archived
+func LatestPrompt(name, projectName string) (*PromptTemplate, error) {
+	return scanPrompt(DB.QueryRow(`SELECT `+promptColumns+` FROM prompt_templates
+		WHERE name = ? AND project_name = ? AND archived = 0
+		ORDER BY version DESC LIMIT 1;`, name, projectName))
+}
+
+func GetPromptVersion(name, projectName string, version int) (*PromptTemplate, error) {
+	return scanPrompt(DB.QueryRow(`SELECT `+promptColumns+` FROM prompt_templates
+		WHERE name = ? AND project_name = ? AND version = ?;`, name, projectName, version))
+}
+
+// ListPromptVersions returns every version of name for projectName, newest first
+func ListPromptVersions(name, projectName string) ([]PromptTemplate, error) {
+	rows, err := DB.Query(`SELECT `+promptColumns+` FROM prompt_templates
+		WHERE name = ? AND project_name = ? ORDER BY version DESC;`, name, projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []PromptTemplate
+	for rows.Next() {
+		p, err := scanPrompt(rows)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, *p)
+	}
+	return prompts, rows.Err()
+}
+
+// ArchivePrompts retires every version of name for projectName, so lookups
+// fall back to the global or built-in template. History is kept.
+func ArchivePrompts(name, projectName string) (int64, error) {
+	res, err := DB.Exec(`UPDATE prompt_templates SET archived = 1 WHERE name = ? AND project_name = ? AND archived = 0;`, name, projectName)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -2,24 +2,104 @@ package database
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// Job states
+const (
+	StatusPending         = "PENDING"          // waiting for a worker
+	StatusQueued          = "QUEUED"           // failed once, waiting for its backoff to elapse
+	StatusProcessing      = "PROCESSING"       // claimed by a worker
+	StatusWaitingApproval = "WAITING_APPROVAL" // generated, needs human review
+	StatusApproved        = "APPROVED"
+	StatusPendingRetry    = "PENDING-RETRY" // out of attempts, needs a human to confirm a retry
+	StatusFailed          = "FAILED"        // unrecoverable
+)
+
 // QueueItem represents a single job in the system
 type QueueItem struct {
-	ID                   int64
-	ProjectName          string
-	RawNotes             string
-	Status               string // PENDING, QUEUED, PROCESSING, WAITING_APPROVAL, APPROVED, PENDING-RETRY, FAILED
-	Priority             string // NORMAL, HIGH
-	AttemptCount         int
-	CreatedAt            string
-	GeneratedSubject     string
-	GeneratedContent     string
-	GeneratedTags        string
-	ApprovalToken        string
-	LastNotificationSent time.Time
-	ErrorMsg             string
+	ID                   int64     `json:"id"`
+	ProjectName          string    `json:"project_name"`
+	RawNotes             string    `json:"raw_notes"`
+	FeedType             string    `json:"feed_type"`
+	Status               string    `json:"status"`   // PENDING, QUEUED, PROCESSING, WAITING_APPROVAL, APPROVED, PENDING-RETRY, FAILED
+	Priority             string    `json:"priority"` // NORMAL, HIGH
+	AttemptCount         int       `json:"attempt_count"`
+	MaxAttempts          int       `json:"max_attempts"`
+	NextAttemptAt        string    `json:"next_attempt_at,omitempty"`
+	CreatedAt            string    `json:"created_at"`
+	UpdatedAt            string    `json:"updated_at,omitempty"`
+	GeneratedSubject     string    `json:"generated_subject,omitempty"`
+	GeneratedContent     string    `json:"generated_content,omitempty"`
+	GeneratedTags        string    `json:"generated_tags,omitempty"`
+	Provider             string    `json:"provider,omitempty"`
+	ApprovalToken        string    `json:"-"`
+	ApprovalExpiresAt    string    `json:"approval_expires_at,omitempty"`
+	LastNotificationSent time.Time `json:"-"`
+	ErrorMsg             string    `json:"error_msg,omitempty"`
+	ContentID            int64     `json:"content_id,omitempty"` // row in contents holding the generated feed
+}
+
+// jobColumns is the column list scanned by scanJob. COALESCE keeps rows
+// that predate the queue columns scannable.
+const jobColumns = `id, COALESCE(project_name, ''), COALESCE(raw_notes, ''), COALESCE(feed_type, ''),
+	COALESCE(status, ''), COALESCE(priority, 'NORMAL'), COALESCE(attempt_count, 0), COALESCE(max_attempts, 3),
+	COALESCE(next_attempt_at, ''), COALESCE(created_at, ''), COALESCE(updated_at, ''),
+	COALESCE(generated_subject, ''), COALESCE(generated_content, ''), COALESCE(generated_tags, ''),
+	COALESCE(provider, ''), COALESCE(approval_token, ''), COALESCE(approval_expires_at, ''), COALESCE(error_msg, ''),
+	COALESCE(content_id, 0)`
+
+// SQLiteTime is the layout CURRENT_TIMESTAMP uses, so stored times compare as strings
+const SQLiteTime = "2006-01-02 15:04:05"
+
+func scanJob(row interface{ Scan(...any) error }) (*QueueItem, error) {
+	var i QueueItem
+	err := row.Scan(&i.ID, &i.ProjectName, &i.RawNotes, &i.FeedType,
+		&i.Status, &i.Priority, &i.AttemptCount, &i.MaxAttempts,
+		&i.NextAttemptAt, &i.CreatedAt, &i.UpdatedAt,
+		&i.GeneratedSubject, &i.GeneratedContent, &i.GeneratedTags,
+		&i.Provider, &i.ApprovalToken, &i.ApprovalExpiresAt, &i.ErrorMsg,
+		&i.ContentID)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// ApprovalExpiry parses ApprovalExpiresAt; zero if the job was never sent for approval
+func (i QueueItem) ApprovalExpiry() time.Time {
+	t, _ := time.ParseInLocation(SQLiteTime, i.ApprovalExpiresAt, time.UTC)
+	return t
+}
+
+// --- Producers ---
+
+// EnqueueJob adds a new PENDING job and returns its ID
+func EnqueueJob(projectName, feedType, rawNotes, priority string, maxAttempts int) (int64, error) {
+	return enqueueJob(DB, projectName, feedType, rawNotes, priority, maxAttempts)
+}
+
+func enqueueJob(q querier, projectName, feedType, rawNotes, priority string, maxAttempts int) (int64, error) {
+	if priority == "" {
+		priority = "NORMAL"
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	pid, err := projectID(q, projectName)
+	if err != nil {
+		return 0, err
+	}
+	res, err := q.Exec(`
+		INSERT INTO journal_entries (project_name, project_id, feed_type, raw_notes, status, priority, max_attempts, next_attempt_at, updated_at)
+		VALUES (?, ?, ?, ?, 'PENDING', ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		projectName, pid, feedType, rawNotes, priority, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
 // --- Fetchers ---
@@ -45,25 +125,15 @@ func GetPendingIDs() ([]int64, error) {
 
 // GetEntry fetches a single job by ID (Used by Worker)
 func GetEntry(id int64) (*QueueItem, error) {
-	var item QueueItem
-
-	err := DB.QueryRow(`
-		SELECT id, project_name, raw_notes, status, priority, attempt_count, created_at 
-		FROM journal_entries WHERE id = ?`, id).
-		Scan(&item.ID, &item.ProjectName, &item.RawNotes, &item.Status, &item.Priority, &item.AttemptCount, &item.CreatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-	return &item, nil
+	return scanJob(DB.QueryRow(`SELECT `+jobColumns+` FROM journal_entries WHERE id = ?`, id))
 }
 
 // GetJobsByStatus fetches full details for the Dashboard or Notifier
 func GetJobsByStatus(status string) ([]QueueItem, error) {
 	rows, err := DB.Query(`
-		SELECT id, project_name, status, created_at, generated_subject, generated_content, approval_token 
-		FROM journal_entries 
-		WHERE status = ? 
+		SELECT `+jobColumns+`
+		FROM journal_entries
+		WHERE status = ?
 		ORDER BY id DESC`, status)
 	if err != nil {
 		return nil, err
@@ -72,18 +142,44 @@ func GetJobsByStatus(status string) ([]QueueItem, error) {
 
 	var items []QueueItem
 	for rows.Next() {
-		var i QueueItem
-		var subject, content, token sql.NullString // Handle NULLs safely
+		i, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *i)
+	}
+	return items, rows.Err()
+}
 
-		if err := rows.Scan(&i.ID, &i.ProjectName, &i.Status, &i.CreatedAt, &subject, &content, &token); err != nil {
+// GetJobsToNotify fetches jobs waiting for approval whose reviewers haven't
+// been told yet, oldest first. Expired ones are skipped.
+func GetJobsToNotify() ([]QueueItem, error) {
+	rows, err := DB.Query(`
+		SELECT ` + jobColumns + `
+		FROM journal_entries
+		WHERE status = 'WAITING_APPROVAL' AND last_notification_sent IS NULL
+		  AND approval_expires_at > CURRENT_TIMESTAMP
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []QueueItem
+	for rows.Next() {
+		i, err := scanJob(rows)
+		if err != nil {
 			return nil, err
 		}
-		i.GeneratedSubject = subject.String
-		i.GeneratedContent = content.String
-		i.ApprovalToken = token.String
-		items = append(items, i)
+		items = append(items, *i)
 	}
-	return items, nil
+	return items, rows.Err()
+}
+
+// MarkNotified records that reviewers were told about the job
+func MarkNotified(id int64) error {
+	_, err := DB.Exec(`UPDATE journal_entries SET last_notification_sent = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
 }
 
 // GetPendingRetryIDs fetches jobs that failed and need human confirmation
@@ -107,39 +203,178 @@ func GetPendingRetryIDs() ([]int64, error) {
 
 // --- State Modifiers ---
 
+// ClaimNextJob atomically moves the next runnable job to PROCESSING and
+// returns it. HIGH priority jobs go first. Returns nil when the queue is empty.
+func ClaimNextJob() (*QueueItem, error) {
+	item, err := scanJob(DB.QueryRow(`
+		UPDATE journal_entries
+		SET status = 'PROCESSING', updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM journal_entries
+			WHERE status IN ('PENDING', 'QUEUED')
+			  AND COALESCE(next_attempt_at, created_at) <= CURRENT_TIMESTAMP
+			ORDER BY CASE priority WHEN 'HIGH' THEN 0 ELSE 1 END, id
+			LIMIT 1
+		) AND status IN ('PENDING', 'QUEUED')
+		RETURNING ` + jobColumns))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return item, err
+}
+
+// ReleaseStaleJobs puts jobs left in PROCESSING by a previous run back in the queue
+func ReleaseStaleJobs() (int64, error) {
+	res, err := DB.Exec(`
+		UPDATE journal_entries
+		SET status = 'PENDING', updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'PROCESSING'`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // UpdateStatus moves a job to a new state
 func UpdateStatus(id int64, status string) error {
-	_, err := DB.Exec("UPDATE journal_entries SET status = ? WHERE id = ?", status, id)
+	_, err := DB.Exec("UPDATE journal_entries SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", status, id)
 	return err
 }
 
-// SetApprovalWait saves the LLM result and generates a token for human review
-func SetApprovalWait(id int64, subject, content, tags, token string) error {
-	_, err := DB.Exec(`
-		UPDATE journal_entries 
-		SET status = 'WAITING_APPROVAL', 
-		    generated_subject = ?, 
-		    generated_content = ?, 
-		    generated_tags = ?, 
-		    approval_token = ? 
+// SetApprovalWait stores the job's feed and saves the LLM result with a
+// token for human review, in one transaction so a failure keeps neither
+func SetApprovalWait(id int64, feed *Content, subject, content, tags, token string, expiresAt time.Time) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertContent(tx, feed); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		UPDATE journal_entries
+		SET status = 'WAITING_APPROVAL',
+		    generated_subject = ?,
+		    generated_content = ?,
+		    generated_tags = ?,
+		    provider = ?,
+		    approval_token = ?,
+		    approval_expires_at = ?,
+		    content_id = ?,
+		    error_msg = NULL,
+		    last_notification_sent = NULL,
+		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`,
-		subject, content, tags, token, id)
+		subject, content, tags, feed.Provider, token, expiresAt.UTC().Format(SQLiteTime), feed.ID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// ErrApprovalConflict means the job is no longer waiting for approval with that token
+var ErrApprovalConflict = errors.New("job is not awaiting approval or its token has expired")
+
+// ApproveJob moves a job to APPROVED and burns its token so links can't be reused
+func ApproveJob(id int64, token string) error {
+	return approvalTransition(`
+		UPDATE journal_entries
+		SET status = 'APPROVED',
+		    approval_token = NULL,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'WAITING_APPROVAL' AND approval_token = ? AND approval_expires_at > CURRENT_TIMESTAMP`,
+		id, token)
+}
+
+// RejectJob sends a job back into the queue for a fresh generation
+func RejectJob(id int64, token string) error {
+	return approvalTransition(`
+		UPDATE journal_entries
+		SET status = 'PENDING',
+		    attempt_count = 0,
+		    next_attempt_at = CURRENT_TIMESTAMP,
+		    approval_token = NULL,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'WAITING_APPROVAL' AND approval_token = ? AND approval_expires_at > CURRENT_TIMESTAMP`,
+		id, token)
+}
+
+// EditJobContent saves a reviewer's changes; the job stays in WAITING_APPROVAL
+func EditJobContent(id int64, token, subject, content string) error {
+	return approvalTransition(`
+		UPDATE journal_entries
+		SET generated_subject = ?,
+		    generated_content = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'WAITING_APPROVAL' AND approval_token = ? AND approval_expires_at > CURRENT_TIMESTAMP`,
+		subject, content, id, token)
+}
+
+func approvalTransition(query string, args ...any) error {
+	res, err := DB.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrApprovalConflict
+	}
+	return nil
+}
+
+// ScheduleRetry records a failed attempt and parks the job in QUEUED until the backoff elapses
+func ScheduleRetry(id int64, delay time.Duration, errMsg string) error {
+	_, err := DB.Exec(`
+		UPDATE journal_entries
+		SET status = 'QUEUED',
+		    attempt_count = attempt_count + 1,
+		    next_attempt_at = datetime('now', ?),
+		    error_msg = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, fmt.Sprintf("+%d seconds", int(delay.Seconds())), errMsg, id)
 	return err
 }
 
 // MarkRetry increments retry count and sets status to PENDING-RETRY (Human intervention needed)
-func MarkRetry(id int64) error {
+func MarkRetry(id int64, errMsg string) error {
 	_, err := DB.Exec(`
-		UPDATE journal_entries 
-		SET status = 'PENDING-RETRY', 
-		    attempt_count = attempt_count + 1 
-		WHERE id = ?`, id)
+		UPDATE journal_entries
+		SET status = 'PENDING-RETRY',
+		    attempt_count = attempt_count + 1,
+		    error_msg = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, errMsg, id)
+	return err
+}
+
+// MarkFailed sets a job to FAILED for errors a retry can't fix
+func MarkFailed(id int64, errMsg string) error {
+	_, err := DB.Exec(`
+		UPDATE journal_entries
+		SET status = 'FAILED',
+		    error_msg = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, errMsg, id)
 	return err
 }
 
 // ResetRetryCount is called when a human manually confirms a retry
 func ResetRetryCount(id int64) error {
-	_, err := DB.Exec("UPDATE journal_entries SET attempt_count = 0 WHERE id = ?", id)
+	_, err := DB.Exec(`
+		UPDATE journal_entries
+		SET attempt_count = 0,
+		    status = 'PENDING',
+		    next_attempt_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, id)
 	return err
 }
 
@@ -148,7 +383,7 @@ func ResetRetryCount(id int64) error {
 // GetToken fetches the secure token to verify approval links
 func GetToken(idStr string) (string, error) {
 	var token string
-	err := DB.QueryRow("SELECT approval_token FROM journal_entries WHERE id = ?", idStr).Scan(&token)
+	err := DB.QueryRow("SELECT COALESCE(approval_token, '') FROM journal_entries WHERE id = ?", idStr).Scan(&token)
 	if err != nil {
 		return "", err
 	}
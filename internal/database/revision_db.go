@@ -0,0 +1,91 @@
+package database
+
+import "database/sql"
+
+// Revision kinds
+const (
+	RevisionGenerated   = "generated"   // first output of a fresh generation
+	RevisionRegenerated = "regenerated" // first output of a regenerated version
+	RevisionEdited      = "edited"      // a human changed the text
+	RevisionRestored    = "restored"    // an older revision was made current again
+)
+
+// Revision is one entry in a feed's append-only text history
+type Revision struct {
+	ID           int64  `json:"id"`
+	ContentID    int64  `json:"content_id"`
+	Kind         string `json:"kind"`
+	Author       string `json:"author"`
+	Output       string `json:"output"`
+	RestoredFrom int64  `json:"restored_from,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+const revisionColumns = `id, content_id, kind, COALESCE(author, ''), COALESCE(output, ''), COALESCE(restored_from, 0), created_at`
+
+// familyOf selects every contents ID regenerated from the same first version as ?
+const familyOf = `SELECT id FROM contents WHERE COALESCE(root_id, id) = (SELECT COALESCE(root_id, id) FROM contents WHERE id = ?)`
+
+func scanRevision(row interface{ Scan(...any) error }) (*Revision, error) {
+	var rev Revision
+	if err := row.Scan(&rev.ID, &rev.ContentID, &rev.Kind, &rev.Author, &rev.Output, &rev.RestoredFrom, &rev.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func insertRevision(tx *sql.Tx, contentID int64, kind, author, output string, restoredFrom int64) (*Revision, error) {
+	var from any
+	if restoredFrom != 0 {
+		from = restoredFrom
+	}
+	return scanRevision(tx.QueryRow(`
+		INSERT INTO content_revisions (content_id, kind, author, output, restored_from)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING `+revisionColumns+`;`, contentID, kind, author, output, from))
+}
+
+// Revise makes output the feed's current text and appends it to the history
+func (r *ContentRepository) Revise(contentID int64, output, kind, author string, restoredFrom int64) (*Revision, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE contents SET output = ? WHERE id = ?;`, output, contentID); err != nil {
+		return nil, err
+	}
+	rev, err := insertRevision(tx, contentID, kind, author, output, restoredFrom)
+	if err != nil {
+		return nil, err
+	}
+	return rev, tx.Commit()
+}
+
+// Revisions lists the history of a feed and every version regenerated
+// alongside it, oldest first
+func (r *ContentRepository) Revisions(contentID int64) ([]Revision, error) {
+	rows, err := r.db.Query(`SELECT `+revisionColumns+` FROM content_revisions
+		WHERE content_id IN (`+familyOf+`) ORDER BY id;`, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+// Revision fetches one revision, provided it belongs to contentID's history
+func (r *ContentRepository) Revision(contentID, revisionID int64) (*Revision, error) {
+	return scanRevision(r.db.QueryRow(`SELECT `+revisionColumns+` FROM content_revisions
+		WHERE id = ? AND content_id IN (`+familyOf+`);`, revisionID, contentID))
+}
@@ -0,0 +1,19 @@
+package schema
+
+// APIKeysDBSchema holds the REST API keys. Only the SHA-256 of a key is
+// stored; prefix is its first characters, to tell keys apart in listings.
+// scopes and projects are comma-separated, no projects meaning all of them.
+var APIKeysDBSchema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL,
+	projects TEXT NOT NULL DEFAULT '',
+	rotated_from INTEGER REFERENCES api_keys(id),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME,
+	last_used_at DATETIME,
+	revoked_at DATETIME
+);`
@@ -0,0 +1,13 @@
+package schema
+
+// ApprovalAuditDBSchema records every approve/reject/edit made on a job
+var ApprovalAuditDBSchema = `
+CREATE TABLE IF NOT EXISTS approval_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT,
+	detail TEXT,
+	remote_addr TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
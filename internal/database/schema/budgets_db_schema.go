@@ -0,0 +1,16 @@
+package schema
+
+// BudgetsDBSchema caps LLM usage per period. project_name is empty for the
+// global budget; a zero limit is unlimited.
+var BudgetsDBSchema = `
+CREATE TABLE IF NOT EXISTS budgets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_name TEXT NOT NULL DEFAULT '',
+	period TEXT NOT NULL,
+	max_tokens INTEGER NOT NULL DEFAULT 0,
+	max_cost_usd REAL NOT NULL DEFAULT 0,
+	action TEXT NOT NULL DEFAULT 'reject',
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (project_name, period)
+);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_project ON llm_usage (project_name, created_at);`
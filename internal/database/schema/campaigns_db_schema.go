@@ -0,0 +1,17 @@
+package schema
+
+// CampaignsDBSchema groups the feeds generated together by POST /generate.
+// failures maps each platform that failed to its error.
+var CampaignsDBSchema = `
+CREATE TABLE IF NOT EXISTS campaigns (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_name TEXT,
+	raw_notes TEXT,
+	platforms TEXT,
+	status TEXT NOT NULL DEFAULT 'running',
+	failures TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	completed_at DATETIME
+);
+ALTER TABLE contents ADD COLUMN campaign_id INTEGER REFERENCES campaigns(id);
+CREATE INDEX IF NOT EXISTS idx_contents_campaign ON contents (campaign_id);`
@@ -0,0 +1,23 @@
+package schema
+
+// ContentRevisionsDBSchema is the append-only history of every feed's text.
+// Rows are never updated or deleted by the application.
+var ContentRevisionsDBSchema = `
+CREATE TABLE IF NOT EXISTS content_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	content_id INTEGER NOT NULL REFERENCES contents(id),
+	kind TEXT NOT NULL,
+	author TEXT,
+	output TEXT,
+	restored_from INTEGER REFERENCES content_revisions(id),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_content_revisions_content ON content_revisions (content_id);
+
+-- Every existing feed starts with its current text as the first revision
+INSERT INTO content_revisions (content_id, kind, author, output, created_at)
+SELECT id,
+       CASE WHEN parent_id IS NULL THEN 'generated' ELSE 'regenerated' END,
+       CASE WHEN provider IS NULL THEN 'import' ELSE 'llm:' || provider END,
+       output, created_at
+FROM contents ORDER BY id;`
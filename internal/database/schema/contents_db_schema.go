@@ -0,0 +1,70 @@
+package schema
+
+// ContentsDBSchema holds every generated feed, whatever the platform
+var ContentsDBSchema = `
+CREATE TABLE IF NOT EXISTS contents (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	platform TEXT NOT NULL,
+	project_name TEXT,
+	raw_notes TEXT,
+	output TEXT,
+	metadata TEXT,
+	provider TEXT,
+	model TEXT,
+	latency_ms INTEGER,
+	status TEXT DEFAULT 'generated',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_contents_platform_project ON contents (platform, project_name);
+CREATE INDEX IF NOT EXISTS idx_contents_platform_created ON contents (platform, created_at);`
+
+// LegacyFeedTables maps the old one-table-per-platform layout onto platforms.
+// Migration 002 copies their rows into contents and drops them.
+var LegacyFeedTables = [][2]string{
+	{"instagram_feeds", "instagram"},
+	{"newsletters", "newsletter"},
+	{"linkedin_feeds", "linkedin"},
+	{"twitter_feeds", "twitter"},
+}
+
+// RestoreLegacyFeedTables rebuilds the per-platform tables from the rows
+// migration 002 imported, keeping their original IDs.
+var RestoreLegacyFeedTables = `
+CREATE TABLE IF NOT EXISTS instagram_feeds (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	feed TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	project_name TEXT
+);
+CREATE TABLE IF NOT EXISTS newsletters (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	feed TEXT,
+	project_name TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS linkedin_feeds (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	feed TEXT,
+	project_name TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS twitter_feeds (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	feed TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	project_name TEXT
+);
+INSERT INTO instagram_feeds (id, feed, project_name, created_at)
+	SELECT json_extract(metadata, '$.legacy_id'), output, project_name, created_at FROM contents
+	WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') = 'instagram_feeds';
+INSERT INTO newsletters (id, feed, project_name, created_at)
+	SELECT json_extract(metadata, '$.legacy_id'), output, project_name, created_at FROM contents
+	WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') = 'newsletters';
+INSERT INTO linkedin_feeds (id, feed, project_name, created_at)
+	SELECT json_extract(metadata, '$.legacy_id'), output, project_name, created_at FROM contents
+	WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') = 'linkedin_feeds';
+INSERT INTO twitter_feeds (id, feed, project_name, created_at)
+	SELECT json_extract(metadata, '$.legacy_id'), output, project_name, created_at FROM contents
+	WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') = 'twitter_feeds';
+DELETE FROM contents
+	WHERE json_valid(metadata) AND json_extract(metadata, '$.imported_from') IN ('instagram_feeds', 'newsletters', 'linkedin_feeds', 'twitter_feeds');`
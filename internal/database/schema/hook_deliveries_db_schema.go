@@ -0,0 +1,21 @@
+package schema
+
+// HookDeliveriesDBSchema logs incoming forge webhooks. delivery_id is the
+// sender's ID, NULL if it sent none; redeliveries of an ID are skipped.
+// job_ids is comma-separated. add_hook_delivery_digest adds digest, the
+// request's replay key, unique per source so a resend is skipped even
+// under a fresh delivery ID.
+var HookDeliveriesDBSchema = `
+CREATE TABLE IF NOT EXISTS hook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source TEXT NOT NULL,
+	delivery_id TEXT,
+	event TEXT,
+	repo TEXT,
+	project_id INTEGER REFERENCES projects(id),
+	status TEXT NOT NULL,
+	detail TEXT,
+	job_ids TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (source, delivery_id)
+);`
@@ -0,0 +1,26 @@
+package schema
+
+// JournalEntriesDBSchema is the job queue driven by the background workers
+var JournalEntriesDBSchema = `
+CREATE TABLE IF NOT EXISTS journal_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_name TEXT,
+	raw_notes TEXT,
+	feed_type TEXT,
+	status TEXT DEFAULT 'PENDING',
+	priority TEXT DEFAULT 'NORMAL',
+	attempt_count INTEGER DEFAULT 0,
+	max_attempts INTEGER DEFAULT 3,
+	next_attempt_at DATETIME,
+	generated_subject TEXT,
+	generated_content TEXT,
+	generated_tags TEXT,
+	provider TEXT,
+	approval_token TEXT,
+	approval_expires_at DATETIME,
+	last_notification_sent DATETIME,
+	error_msg TEXT,
+	content_id INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME
+);`
@@ -0,0 +1,23 @@
+package schema
+
+// LLMUsageDBSchema is the ledger of every LLM call: one row per request
+// sent to a provider, failed generations and repairs included. Failed
+// calls get a status and error from add_llm_usage_status. Contents also
+// get the summed usage of the calls that produced them.
+var LLMUsageDBSchema = `
+CREATE TABLE IF NOT EXISTS llm_usage (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_name TEXT,
+	platform TEXT,
+	provider TEXT NOT NULL,
+	model TEXT,
+	prompt_tokens INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	latency_ms INTEGER NOT NULL DEFAULT 0,
+	cost_usd REAL NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created ON llm_usage (created_at);
+ALTER TABLE contents ADD COLUMN prompt_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE contents ADD COLUMN completion_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE contents ADD COLUMN cost_usd REAL NOT NULL DEFAULT 0;`
@@ -0,0 +1,122 @@
+package schema
+
+// ProjectDeleteRules rebuilds the tables whose rows belong to a project so
+// deleting it cleans them up: its webhook subscriptions (and their
+// deliveries) go with it, logged forge deliveries keep their row without
+// the project. Feeds, jobs and campaigns keep the default NO ACTION rule,
+// so a project that has any can't be deleted; archive it instead.
+var ProjectDeleteRules = `
+CREATE TABLE webhooks_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	events TEXT NOT NULL,
+	project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+	secret TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO webhooks_new SELECT id, url, events, project_id, secret, description, active, created_at, updated_at FROM webhooks;
+DROP TABLE webhooks;
+ALTER TABLE webhooks_new RENAME TO webhooks;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+CREATE TABLE webhook_deliveries_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	response_code INTEGER,
+	response_body TEXT,
+	error TEXT,
+	redelivery_of INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	delivered_at DATETIME
+);
+INSERT INTO webhook_deliveries_new SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at,
+	response_code, response_body, error, redelivery_of, created_at, delivered_at FROM webhook_deliveries;
+DROP TABLE webhook_deliveries;
+ALTER TABLE webhook_deliveries_new RENAME TO webhook_deliveries;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+
+CREATE TABLE hook_deliveries_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source TEXT NOT NULL,
+	delivery_id TEXT,
+	event TEXT,
+	repo TEXT,
+	project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+	status TEXT NOT NULL,
+	detail TEXT,
+	job_ids TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (source, delivery_id)
+);
+INSERT INTO hook_deliveries_new SELECT id, source, delivery_id, event, repo, project_id, status, detail, job_ids, created_at FROM hook_deliveries;
+DROP TABLE hook_deliveries;
+ALTER TABLE hook_deliveries_new RENAME TO hook_deliveries;`
+
+// RestoreProjectDeleteRules rebuilds the tables as create_webhooks and
+// create_hook_deliveries made them
+var RestoreProjectDeleteRules = `
+CREATE TABLE hook_deliveries_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source TEXT NOT NULL,
+	delivery_id TEXT,
+	event TEXT,
+	repo TEXT,
+	project_id INTEGER REFERENCES projects(id),
+	status TEXT NOT NULL,
+	detail TEXT,
+	job_ids TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (source, delivery_id)
+);
+INSERT INTO hook_deliveries_old SELECT id, source, delivery_id, event, repo, project_id, status, detail, job_ids, created_at FROM hook_deliveries;
+DROP TABLE hook_deliveries;
+ALTER TABLE hook_deliveries_old RENAME TO hook_deliveries;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+CREATE TABLE webhook_deliveries_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id),
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	response_code INTEGER,
+	response_body TEXT,
+	error TEXT,
+	redelivery_of INTEGER REFERENCES webhook_deliveries(id),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	delivered_at DATETIME
+);
+INSERT INTO webhook_deliveries_old SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at,
+	response_code, response_body, error, redelivery_of, created_at, delivered_at FROM webhook_deliveries;
+DROP TABLE webhook_deliveries;
+ALTER TABLE webhook_deliveries_old RENAME TO webhook_deliveries;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+
+CREATE TABLE webhooks_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	events TEXT NOT NULL,
+	project_id INTEGER REFERENCES projects(id),
+	secret TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO webhooks_old SELECT id, url, events, project_id, secret, description, active, created_at, updated_at FROM webhooks;
+DROP TABLE webhooks;
+ALTER TABLE webhooks_old RENAME TO webhooks;`
@@ -0,0 +1,37 @@
+package schema
+
+// ProjectsDBSchema makes projects first-class. Feeds, jobs and campaigns
+// reference them by project_id; project_name stays as the name the request
+// used. default_platforms is comma-separated, hashtags a JSON array.
+// voice_id replaces the project_voices table.
+var ProjectsDBSchema = `
+CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	slug TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	description TEXT,
+	voice_id INTEGER REFERENCES voice_profiles(id),
+	default_platforms TEXT,
+	hashtags TEXT,
+	repo_url TEXT,
+	archived INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE contents ADD COLUMN project_id INTEGER REFERENCES projects(id);
+ALTER TABLE journal_entries ADD COLUMN project_id INTEGER REFERENCES projects(id);
+ALTER TABLE campaigns ADD COLUMN project_id INTEGER REFERENCES projects(id);
+CREATE INDEX IF NOT EXISTS idx_contents_project ON contents (project_id, id);`
+
+// RestoreProjectVoices brings back the project_voices table, keyed by slug
+var RestoreProjectVoices = `
+CREATE TABLE IF NOT EXISTS project_voices (
+	project_name TEXT PRIMARY KEY,
+	voice_id INTEGER NOT NULL REFERENCES voice_profiles(id)
+);
+INSERT INTO project_voices (project_name, voice_id) SELECT slug, voice_id FROM projects WHERE voice_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_contents_project;
+ALTER TABLE campaigns DROP COLUMN project_id;
+ALTER TABLE journal_entries DROP COLUMN project_id;
+ALTER TABLE contents DROP COLUMN project_id;
+DROP TABLE IF EXISTS projects;`
@@ -0,0 +1,16 @@
+package schema
+
+// PromptTemplatesDBSchema stores every version of every prompt template.
+// An empty project_name is the global template; versions are never edited.
+var PromptTemplatesDBSchema = `
+CREATE TABLE IF NOT EXISTS prompt_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	project_name TEXT NOT NULL DEFAULT '',
+	version INTEGER NOT NULL,
+	body TEXT NOT NULL,
+	author TEXT,
+	archived INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (name, project_name, version)
+);`
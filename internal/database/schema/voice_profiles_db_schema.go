@@ -0,0 +1,23 @@
+package schema
+
+// VoiceProfilesDBSchema holds brand voices and which project uses which.
+// banned_words and examples are JSON arrays.
+var VoiceProfilesDBSchema = `
+CREATE TABLE IF NOT EXISTS voice_profiles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	persona TEXT,
+	tone TEXT,
+	audience TEXT,
+	emoji TEXT,
+	signature TEXT,
+	banned_words TEXT,
+	examples TEXT,
+	version INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS project_voices (
+	project_name TEXT PRIMARY KEY,
+	voice_id INTEGER NOT NULL REFERENCES voice_profiles(id)
+);`
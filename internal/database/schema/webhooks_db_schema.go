@@ -0,0 +1,36 @@
+package schema
+
+// WebhooksDBSchema holds the outbound webhook subscriptions. events is
+// comma-separated; a NULL project_id subscribes to every project. The
+// secret signs deliveries, so unlike API keys it is stored as is.
+var WebhooksDBSchema = `
+CREATE TABLE IF NOT EXISTS webhooks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	events TEXT NOT NULL,
+	project_id INTEGER REFERENCES projects(id),
+	secret TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id),
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	response_code INTEGER,
+	response_body TEXT,
+	error TEXT,
+	redelivery_of INTEGER REFERENCES webhook_deliveries(id),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	delivered_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);`
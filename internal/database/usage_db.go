@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// UsageRecord is one LLM call in the llm_usage ledger
+type UsageRecord struct {
+	ProjectName      string
+	Platform         string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	LatencyMS        int64
+	CostUSD          float64
+	Status           string // ok, failed, blocked or cancelled; empty is ok
+	Error            string
+}
+
+func InsertUsage(u *UsageRecord) error {
+	status := u.Status
+	if status == "" {
+		status = "ok"
+	}
+	_, err := DB.Exec(`
+		INSERT INTO llm_usage (project_name, platform, provider, model, prompt_tokens, completion_tokens, latency_ms, cost_usd, status, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''));`,
+		u.ProjectName, u.Platform, u.Provider, u.Model, u.PromptTokens, u.CompletionTokens, u.LatencyMS, u.CostUSD, status, u.Error)
+	return err
+}
+
+// UsageGroups are the dimensions a usage report can be grouped by, mapped
+// to their column
+var UsageGroups = map[string]string{
+	"project":  "COALESCE(project_name, '')",
+	"platform": "COALESCE(platform, '')",
+	"provider": "provider",
+	"model":    "COALESCE(model, '')",
+	"day":      "DATE(created_at)",
+}
+
+// UsageFilter narrows a usage report; empty fields match everything.
+// From and To are inclusive UTC days (YYYY-MM-DD).
+type UsageFilter struct {
+	From        string
+	To          string
+	ProjectName string
+	Platform    string
+	Provider    string
+	GroupBy     []string // keys of UsageGroups
+}
+
+// UsageRow is one group of a usage report. Dimensions the report isn't
+// grouped by are left out.
+type UsageRow struct {
+	Project          *string `json:"project,omitempty"`
+	Platform         *string `json:"platform,omitempty"`
+	Provider         *string `json:"provider,omitempty"`
+	Model            *string `json:"model,omitempty"`
+	Day              *string `json:"day,omitempty"`
+	Calls            int     `json:"calls"`
+	FailedCalls      int     `json:"failed_calls"` // calls with any status but ok
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UsageReport sums the ledger per group, ordered by the group columns
+func UsageReport(f UsageFilter) ([]UsageRow, error) {
+	var where []string
+	var args []any
+	for _, cond := range []struct{ sql, value string }{
+		{"DATE(created_at) >= ?", f.From},
+		{"DATE(created_at) <= ?", f.To},
+		{"COALESCE(project_name, '') = ?", f.ProjectName},
+		{"platform = ?", f.Platform},
+		{"provider = ?", f.Provider},
+	} {
+		if cond.value != "" {
+			where = append(where, cond.sql)
+			args = append(args, cond.value)
+		}
+	}
+
+	var groups []string
+	for _, g := range f.GroupBy {
+		col, ok := UsageGroups[g]
+		if !ok {
+			return nil, fmt.Errorf("unknown usage group: %s", g)
+		}
+		groups = append(groups, col)
+	}
+
+	// Every dimension is selected; the ones not grouped by come back NULL
+	var cols []string
+	for _, g := range []string{"project", "platform", "provider", "model", "day"} {
+		col := "NULL"
+		for _, wanted := range f.GroupBy {
+			if wanted == g {
+				col = UsageGroups[g]
+			}
+		}
+		cols = append(cols, col)
+	}
+
+	query := `SELECT ` + strings.Join(cols, ", ") + `,
+		COUNT(*), COALESCE(SUM(status != 'ok'), 0), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), ROUND(COALESCE(SUM(cost_usd), 0), 8)
+		FROM llm_usage`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	if len(groups) > 0 {
+		query += ` GROUP BY ` + strings.Join(groups, ", ") + ` ORDER BY ` + strings.Join(groups, ", ")
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []UsageRow{}
+	for rows.Next() {
+		var r UsageRow
+		var dims [5]sql.NullString
+		if err := rows.Scan(&dims[0], &dims[1], &dims[2], &dims[3], &dims[4],
+			&r.Calls, &r.FailedCalls, &r.PromptTokens, &r.CompletionTokens, &r.CostUSD); err != nil {
+			return nil, err
+		}
+		for i, dst := range []**string{&r.Project, &r.Platform, &r.Provider, &r.Model, &r.Day} {
+			if dims[i].Valid {
+				*dst = &dims[i].String
+			}
+		}
+		report = append(report, r)
+	}
+	return report, rows.Err()
+}
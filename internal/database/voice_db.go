@@ -0,0 +1,157 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Emoji preferences of a voice profile
+const (
+	EmojiNone  = "none"
+	EmojiLight = "light"
+	EmojiHeavy = "heavy"
+)
+
+// VoiceProfile describes how a brand sounds. It is composed into the system
+// prompt of every generation for the projects (or requests) that use it.
+type VoiceProfile struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Persona     string         `json:"persona,omitempty"` // who is writing, e.g. "a Staff Software Engineer"
+	Tone        string         `json:"tone,omitempty"`
+	Audience    string         `json:"audience,omitempty"`
+	Emoji       string         `json:"emoji,omitempty"` // none, light or heavy; empty leaves it to the prompt
+	Signature   string         `json:"signature,omitempty"`
+	BannedWords []string       `json:"banned_words,omitempty"`
+	Examples    []VoiceExample `json:"examples,omitempty"`
+	Version     int            `json:"version"` // bumped on every update
+	CreatedAt   string         `json:"created_at"`
+	UpdatedAt   string         `json:"updated_at"`
+}
+
+// VoiceExample is a past post used as a few-shot sample. An empty Platform
+// applies to every platform.
+type VoiceExample struct {
+	Platform string `json:"platform,omitempty"`
+	Text     string `json:"text"`
+}
+
+var ErrVoiceExists = errors.New("voice profile already exists")
+
+const voiceColumns = `id, name, COALESCE(persona, ''), COALESCE(tone, ''), COALESCE(audience, ''), COALESCE(emoji, ''),
+	COALESCE(signature, ''), COALESCE(banned_words, ''), COALESCE(examples, ''), version, created_at, updated_at`
+
+func scanVoice(row interface{ Scan(...any) error }) (*VoiceProfile, error) {
+	var v VoiceProfile
+	var banned, examples string
+	if err := row.Scan(&v.ID, &v.Name, &v.Persona, &v.Tone, &v.Audience, &v.Emoji,
+		&v.Signature, &banned, &examples, &v.Version, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if banned != "" {
+		json.Unmarshal([]byte(banned), &v.BannedWords)
+	}
+	if examples != "" {
+		json.Unmarshal([]byte(examples), &v.Examples)
+	}
+	return &v, nil
+}
+
+func voiceLists(v *VoiceProfile) (banned, examples string) {
+	b, _ := json.Marshal(v.BannedWords)
+	e, _ := json.Marshal(v.Examples)
+	return string(b), string(e)
+}
+
+// InsertVoice creates a profile and fills in its ID and timestamps
+func InsertVoice(v *VoiceProfile) error {
+	if _, err := GetVoice(v.Name); err == nil {
+		return ErrVoiceExists
+	}
+	banned, examples := voiceLists(v)
+	return DB.QueryRow(`
+		INSERT INTO voice_profiles (name, persona, tone, audience, emoji, signature, banned_words, examples)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, version, created_at, updated_at;`,
+		v.Name, v.Persona, v.Tone, v.Audience, v.Emoji, v.Signature, banned, examples,
+	).Scan(&v.ID, &v.Version, &v.CreatedAt, &v.UpdatedAt)
+}
+
+// UpdateVoice replaces every field of the named profile and bumps its version
+func UpdateVoice(v *VoiceProfile) error {
+	banned, examples := voiceLists(v)
+	return DB.QueryRow(`
+		UPDATE voice_profiles
+		SET persona = ?, tone = ?, audience = ?, emoji = ?, signature = ?, banned_words = ?, examples = ?,
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE name = ?
+		RETURNING id, version, created_at, updated_at;`,
+		v.Persona, v.Tone, v.Audience, v.Emoji, v.Signature, banned, examples, v.Name,
+	).Scan(&v.ID, &v.Version, &v.CreatedAt, &v.UpdatedAt)
+}
+
+func GetVoice(name string) (*VoiceProfile, error) {
+	return scanVoice(DB.QueryRow(`SELECT `+voiceColumns+` FROM voice_profiles WHERE name = ?;`, name))
+}
+
+func ListVoices() ([]VoiceProfile, error) {
+	rows, err := DB.Query(`SELECT ` + voiceColumns + ` FROM voice_profiles ORDER BY name;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	voices := []VoiceProfile{}
+	for rows.Next() {
+		v, err := scanVoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		voices = append(voices, *v)
+	}
+	return voices, rows.Err()
+}
+
+// DeleteVoice removes a profile and detaches it from every project
+func DeleteVoice(name string) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE projects SET voice_id = NULL WHERE voice_id = (SELECT id FROM voice_profiles WHERE name = ?);`, name); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`DELETE FROM voice_profiles WHERE name = ?;`, name)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// SetProjectVoice attaches a profile to a project; an empty name detaches
+// it. Returns ErrUnknownProject if there is no such project.
+func SetProjectVoice(projectName, voiceName string) error {
+	var voiceID any
+	if voiceName != "" {
+		v, err := GetVoice(voiceName)
+		if err != nil {
+			return err
+		}
+		voiceID = v.ID
+	}
+	if _, err := projectID(DB, projectName); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`UPDATE projects SET voice_id = ?, updated_at = CURRENT_TIMESTAMP WHERE slug = ?;`,
+		voiceID, Slugify(projectName))
+	return err
+}
+
+// ProjectVoice returns the profile attached to a project (sql.ErrNoRows if none)
+func ProjectVoice(projectName string) (*VoiceProfile, error) {
+	return scanVoice(DB.QueryRow(`SELECT `+voiceColumns+` FROM voice_profiles
+		WHERE id = (SELECT voice_id FROM projects WHERE slug = ?);`, Slugify(projectName)))
+}
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Outbound webhook delivery states
+const (
+	DeliveryPending   = "pending"   // waiting for its next attempt
+	DeliveryDelivered = "delivered" // the receiver answered 2xx
+	DeliveryFailed    = "failed"    // out of attempts
+)
+
+// Webhook is a subscription to lifecycle events. Secret is only returned
+// when the subscription is created or its secret rotated.
+type Webhook struct {
+	ID          int64    `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Project     string   `json:"project,omitempty"` // slug; empty subscribes to every project
+	Secret      string   `json:"secret,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Active      bool     `json:"active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a subscription
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt string          `json:"next_attempt_at,omitempty"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	ResponseBody  string          `json:"response_body,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	RedeliveryOf  int64           `json:"redelivery_of,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+	DeliveredAt   string          `json:"delivered_at,omitempty"`
+}
+
+const webhookColumns = `w.id, w.url, w.events, COALESCE(p.slug, ''), w.secret, w.description, w.active,
+	w.created_at, w.updated_at`
+
+const webhookFrom = ` FROM webhooks w LEFT JOIN projects p ON p.id = w.project_id`
+
+func scanWebhook(row interface{ Scan(...any) error }) (*Webhook, error) {
+	var h Webhook
+	var events string
+	err := row.Scan(&h.ID, &h.URL, &events, &h.Project, &h.Secret, &h.Description, &h.Active,
+		&h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	h.Events = strings.Split(events, ",")
+	return &h, nil
+}
+
+// newWebhookSecret returns a random signing secret, "whsec_" and 48 hex characters
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// webhookProjectID maps a project slug to its id, NULL for none
+func webhookProjectID(slug string) (any, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	p, err := GetProject(slug)
+	if err != nil {
+		return nil, err
+	}
+	return p.ID, nil
+}
+
+// InsertWebhook stores h with a new secret and fills in its ID and Secret.
+// Returns sql.ErrNoRows if h.Project names no project.
+func InsertWebhook(h *Webhook) error {
+	projectID, err := webhookProjectID(h.Project)
+	if err != nil {
+		return err
+	}
+	if h.Secret, err = newWebhookSecret(); err != nil {
+		return err
+	}
+	return DB.QueryRow(`
+		INSERT INTO webhooks (url, events, project_id, secret, description, active)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at;`,
+		h.URL, strings.Join(h.Events, ","), projectID, h.Secret, h.Description, h.Active,
+	).Scan(&h.ID, &h.CreatedAt, &h.UpdatedAt)
+}
+
+// UpdateWebhook replaces everything but the secret. Returns sql.ErrNoRows
+// for an unknown webhook or project.
+func UpdateWebhook(h *Webhook) error {
+	projectID, err := webhookProjectID(h.Project)
+	if err != nil {
+		return err
+	}
+	return DB.QueryRow(`
+		UPDATE webhooks SET url = ?, events = ?, project_id = ?, description = ?, active = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		RETURNING created_at, updated_at;`,
+		h.URL, strings.Join(h.Events, ","), projectID, h.Description, h.Active, h.ID,
+	).Scan(&h.CreatedAt, &h.UpdatedAt)
+}
+
+// RotateWebhookSecret gives the webhook a new secret and returns it
+func RotateWebhookSecret(id int64) (*Webhook, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	res, err := DB.Exec(`UPDATE webhooks SET secret = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;`, secret, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return GetWebhook(id)
+}
+
+// GetWebhook returns a subscription, secret included
+func GetWebhook(id int64) (*Webhook, error) {
+	return scanWebhook(DB.QueryRow(`SELECT `+webhookColumns+webhookFrom+` WHERE w.id = ?;`, id))
+}
+
+// ListWebhooks returns every subscription, secrets included
+func ListWebhooks() ([]Webhook, error) {
+	rows, err := DB.Query(`SELECT ` + webhookColumns + webhookFrom + ` ORDER BY w.id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		h, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *h)
+	}
+	return hooks, rows.Err()
+}
+
+// DeleteWebhook removes a subscription and its delivery log
+func DeleteWebhook(id int64) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?;`, id); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// EnqueueWebhookDeliveries queues payload for every active subscription to
+// event, either for all projects or for the one with this slug. Returns
+// how many deliveries were queued.
+func EnqueueWebhookDeliveries(event, projectSlug string, payload []byte) (int64, error) {
+	res, err := DB.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT w.id, ?1, ?2 FROM webhooks w
+		WHERE w.active = 1 AND (',' || w.events || ',') LIKE '%,' || ?1 || ',%'
+		  AND (w.project_id IS NULL OR w.project_id = (SELECT id FROM projects WHERE slug = ?3));`,
+		event, string(payload), projectSlug)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, COALESCE(next_attempt_at, ''),
+	COALESCE(response_code, 0), COALESCE(response_body, ''), COALESCE(error, ''), COALESCE(redelivery_of, 0),
+	created_at, COALESCE(delivered_at, '')`
+
+func scanDelivery(row interface{ Scan(...any) error }) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	var payload string
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.ResponseCode, &d.ResponseBody, &d.Error, &d.RedeliveryOf,
+		&d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = json.RawMessage(payload)
+	return &d, nil
+}
+
+func queryDeliveries(query string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// DueWebhooks returns the subscriptions with a pending delivery whose next
+// attempt is due, the one waiting longest first
+func DueWebhooks() ([]int64, error) {
+	rows, err := DB.Query(`SELECT webhook_id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+		GROUP BY webhook_id ORDER BY MIN(next_attempt_at), MIN(id);`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DueWebhookDeliveries returns up to limit pending deliveries of a
+// subscription whose next attempt is due, oldest first
+func DueWebhookDeliveries(webhookID int64, limit int) ([]WebhookDelivery, error) {
+	return queryDeliveries(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? AND status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY next_attempt_at, id LIMIT ?;`, webhookID, limit)
+}
+
+// ListWebhookDeliveries returns a subscription's deliveries, newest first,
+// optionally only those with status. Payloads are left out.
+func ListWebhookDeliveries(webhookID int64, status string, limit int) ([]WebhookDelivery, error) {
+	deliveries, err := queryDeliveries(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? AND (? = '' OR status = ?)
+		ORDER BY id DESC LIMIT ?;`, webhookID, status, status, limit)
+	for i := range deliveries {
+		deliveries[i].Payload = nil
+	}
+	return deliveries, err
+}
+
+// GetWebhookDelivery returns one delivery of a subscription
+func GetWebhookDelivery(webhookID, id int64) (*WebhookDelivery, error) {
+	return scanDelivery(DB.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? AND id = ?;`, webhookID, id))
+}
+
+// RecordWebhookAttempt stores the outcome of an attempt. A zero retryIn
+// ends the delivery: delivered if it succeeded, failed otherwise.
+func RecordWebhookAttempt(id int64, ok bool, code int, body, errMsg string, retryIn time.Duration) error {
+	status, next, delivered := DeliveryPending, any(nil), any(nil)
+	switch {
+	case ok:
+		status, delivered = DeliveryDelivered, time.Now().UTC().Format(SQLiteTime)
+	case retryIn > 0:
+		next = time.Now().UTC().Add(retryIn).Format(SQLiteTime)
+	default:
+		status = DeliveryFailed
+	}
+	var respCode any
+	if code != 0 {
+		respCode = code
+	}
+	_, err := DB.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, next_attempt_at = ?, response_code = ?, response_body = ?,
+		    error = ?, delivered_at = ?
+		WHERE id = ?;`,
+		status, next, respCode, body, errMsg, delivered, id)
+	return err
+}
+
+// RedeliverWebhook queues a fresh copy of a delivery, whatever its outcome
+func RedeliverWebhook(webhookID, id int64) (*WebhookDelivery, error) {
+	return scanDelivery(DB.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, redelivery_of)
+		SELECT webhook_id, event, payload, id FROM webhook_deliveries WHERE webhook_id = ? AND id = ?
+		RETURNING `+deliveryColumns+`;`, webhookID, id))
+}
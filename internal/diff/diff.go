@@ -0,0 +1,223 @@
+// Package diff compares two versions of a feed, line by line (unified
+// format) or word by word.
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Op kinds
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Segment is a run of text that is unchanged, added or removed
+type Segment struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs a and b at word granularity. Whitespace is kept as its own
+// token, so joining the equal+insert segments gives back b exactly.
+func Words(a, b string) []Segment {
+	return merge(compare(tokenize(a), tokenize(b)))
+}
+
+// Unified renders a line diff in the format of `diff -u`, with the given
+// number of context lines around each change. Empty when a == b.
+func Unified(fromName, toName, a, b string, context int) string {
+	ops := compare(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for _, h := range hunks(ops, context) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, op := range h.ops {
+			prefix := " "
+			switch op.Op {
+			case Insert:
+				prefix = "+"
+			case Delete:
+				prefix = "-"
+			}
+			out.WriteString(prefix + strings.TrimSuffix(op.Text, "\n") + "\n")
+			if !strings.HasSuffix(op.Text, "\n") {
+				out.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// compare returns the edit script turning a into b, one token per Segment,
+// using the longest common subsequence
+func compare(a, b []string) []Segment {
+	// Common prefix and suffix don't need the quadratic table
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []Segment
+	for _, t := range a[:pre] {
+		ops = append(ops, Segment{Equal, t})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(ma), len(mb)
+	// lcs[i][j] is the LCS length of ma[i:] and mb[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case ma[i] == mb[j]:
+			ops = append(ops, Segment{Equal, ma[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Segment{Delete, ma[i]})
+			i++
+		default:
+			ops = append(ops, Segment{Insert, mb[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Segment{Delete, ma[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Segment{Insert, mb[j]})
+	}
+
+	for _, t := range a[len(a)-suf:] {
+		ops = append(ops, Segment{Equal, t})
+	}
+	return ops
+}
+
+// merge joins consecutive segments with the same op
+func merge(ops []Segment) []Segment {
+	var out []Segment
+	for _, op := range ops {
+		if n := len(out); n > 0 && out[n-1].Op == op.Op {
+			out[n-1].Text += op.Text
+			continue
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// tokenize splits text into alternating runs of word and whitespace characters
+func tokenize(s string) []string {
+	var tokens []string
+	start, space := 0, false
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != space {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// splitLines keeps the trailing newline on every line
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type hunk struct {
+	aStart, aLen, bStart, bLen int
+	ops                        []Segment
+}
+
+// hunks groups changed lines with up to context unchanged lines around them.
+// Changes separated by at most 2*context unchanged lines share a hunk.
+func hunks(ops []Segment, context int) []hunk {
+	var out []hunk
+	for i := 0; i < len(ops); {
+		if ops[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// extend over changes until a long enough run of equal lines
+		end := i
+		for k := i; k < len(ops); k++ {
+			if ops[k].Op != Equal {
+				end = k + 1
+			} else if k-end >= 2*context {
+				break
+			}
+		}
+		from, to := max(i-context, 0), min(end+context, len(ops))
+
+		h := hunk{aStart: 1, bStart: 1, ops: ops[from:to]}
+		for _, op := range ops[:from] {
+			h.aStart, h.bStart = advance(op, h.aStart, h.bStart)
+		}
+		for _, op := range h.ops {
+			h.aLen, h.bLen = advance(op, h.aLen, h.bLen)
+		}
+		out = append(out, h)
+		i = to
+	}
+	return out
+}
+
+// advance counts op against the old and new line numbers
+func advance(op Segment, a, b int) (int, int) {
+	switch op.Op {
+	case Delete:
+		return a + 1, b
+	case Insert:
+		return a, b + 1
+	default:
+		return a + 1, b + 1
+	}
+}
+
+// hunkRange formats the "start,len" part of a hunk header; an empty range
+// points at the line before it, as diff -u does
+func hunkRange(start, n int) string {
+	if n == 0 {
+		start--
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
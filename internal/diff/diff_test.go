@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	nine := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{"equal", nine, nine, 3, ""},
+		{"both empty", "", "", 3, ""},
+		{"one change with context", nine, strings.Replace(nine, "5\n", "five\n", 1), 2,
+			"--- a\n+++ b\n@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n"},
+		{"no context", nine, strings.Replace(nine, "5\n", "five\n", 1), 0,
+			"--- a\n+++ b\n@@ -5 +5 @@\n-5\n+five\n"},
+		{"separate hunks", nine, strings.NewReplacer("4\n", "X\n", "9\n", "Y\n").Replace(nine), 1,
+			"--- a\n+++ b\n@@ -3,3 +3,3 @@\n 3\n-4\n+X\n 5\n@@ -8,2 +8,2 @@\n 8\n-9\n+Y\n"},
+		{"close changes share a hunk", nine, strings.NewReplacer("3\n", "X\n", "6\n", "Y\n").Replace(nine), 1,
+			"--- a\n+++ b\n@@ -2,6 +2,6 @@\n 2\n-3\n+X\n 4\n 5\n-6\n+Y\n 7\n"},
+		{"insertion", "a\nc\n", "a\nb\nc\n", 3,
+			"--- a\n+++ b\n@@ -1,2 +1,3 @@\n a\n+b\n c\n"},
+		{"deletion at the start", "a\nb\n", "b\n", 0,
+			"--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n"},
+		{"no trailing newline", "x\ny", "x\nz", 3,
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+z\n\\ No newline at end of file\n"},
+		{"newline added", "x", "x\n", 3,
+			"--- a\n+++ b\n@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+x\n"},
+		{"from empty", "", "x\ny\n", 3,
+			"--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"to empty", "x\n", "", 3,
+			"--- a\n+++ b\n@@ -1 +0,0 @@\n-x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b, tt.context); got != tt.want {
+				t.Errorf("Unified:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Segment
+	}{
+		{"equal", "the fox", "the fox", []Segment{{Equal, "the fox"}}},
+		{"both empty", "", "", nil},
+		{"replaced word", "the quick fox", "the slow fox",
+			[]Segment{{Equal, "the "}, {Delete, "quick"}, {Insert, "slow"}, {Equal, " fox"}}},
+		{"whitespace change", "the fox", "the  fox",
+			[]Segment{{Equal, "the"}, {Delete, " "}, {Insert, "  "}, {Equal, "fox"}}},
+		{"appended", "the fox", "the fox jumps",
+			[]Segment{{Equal, "the fox"}, {Insert, " jumps"}}},
+		{"from empty", "", "new text", []Segment{{Insert, "new text"}}},
+		{"to empty", "old text", "", []Segment{{Delete, "old text"}}},
+		{"line breaks", "one\ntwo", "one\n\ntwo",
+			[]Segment{{Equal, "one"}, {Delete, "\n"}, {Insert, "\n\n"}, {Equal, "two"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words = %+v, want %+v", got, tt.want)
+			}
+			var a, b strings.Builder
+			for _, s := range got {
+				if s.Op != Insert {
+					a.WriteString(s.Text)
+				}
+				if s.Op != Delete {
+					b.WriteString(s.Text)
+				}
+			}
+			if a.String() != tt.a || b.String() != tt.b {
+				t.Errorf("segments give back %q and %q, want %q and %q", a.String(), b.String(), tt.a, tt.b)
+			}
+		})
+	}
+}
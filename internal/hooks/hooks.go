@@ -0,0 +1,319 @@
+// Package hooks turns git forge webhooks (pushes, releases, merged pull
+// requests) and signed CI notifications into raw notes for generation.
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"vexora-studio/internal/middleware"
+)
+
+// Event kinds that produce notes
+const (
+	KindPush    = "push"
+	KindRelease = "release"
+	KindMerge   = "merge"
+	KindCI      = "ci"
+)
+
+// maxNotes caps the notes of one event; huge pushes would blow the prompt
+const maxNotes = 8000
+
+// maxCommits is how many commit messages of a push make it into the notes
+const maxCommits = 20
+
+// ErrIgnored marks deliveries that are valid but carry nothing to post
+// about: other event types, branch pushes, unmerged pull requests
+var ErrIgnored = errors.New("event ignored")
+
+// Event is what a delivery is about
+type Event struct {
+	Kind     string
+	Repo     string   // owner/name
+	RepoURLs []string // web, clone and ssh URLs, to find the project
+	Project  string   // project slug named in the signed payload, if any
+	Notes    string   // raw notes for generation
+}
+
+// Source is a git forge sending webhooks
+type Source struct {
+	Name        string
+	Signature   middleware.Signature
+	EventHeader string
+	DeliveryID  string // header carrying a unique delivery ID
+	parse       func(event string, body []byte) (*Event, error)
+}
+
+var sources = map[string]Source{
+	"github": {
+		Name:        "github",
+		Signature:   middleware.Signature{Header: "X-Hub-Signature-256"},
+		EventHeader: "X-GitHub-Event",
+		DeliveryID:  "X-GitHub-Delivery",
+		parse:       parseGitHub,
+	},
+	"gitea": {
+		Name:        "gitea",
+		Signature:   middleware.Signature{Header: "X-Gitea-Signature"},
+		EventHeader: "X-Gitea-Event",
+		DeliveryID:  "X-Gitea-Delivery",
+		parse:       parseGitHub, // Gitea mirrors GitHub's payloads
+	},
+	"gitlab": {
+		Name:        "gitlab",
+		Signature:   middleware.Signature{Header: "X-Gitlab-Token", Token: true},
+		EventHeader: "X-Gitlab-Event",
+		DeliveryID:  "X-Gitlab-Event-UUID",
+		parse:       parseGitLab,
+	},
+	// CI pipelines and scripts, signed with a timestamp so captured
+	// requests can't be replayed
+	"vexora": {
+		Name:       "vexora",
+		Signature:  middleware.VexoraSignature,
+		DeliveryID: "X-Vexora-Delivery",
+		parse:      parseVexora,
+	},
+}
+
+// GetSource returns the source registered under name
+func GetSource(name string) (Source, bool) {
+	s, ok := sources[name]
+	return s, ok
+}
+
+// Secrets are the shared secrets of a source, from HOOKS_<SOURCE>_SECRET.
+// A comma-separated list keeps the old secret valid while rotating.
+// Sources without one are disabled.
+func (s Source) Secrets() []string {
+	return middleware.ParseSecrets(os.Getenv("HOOKS_" + strings.ToUpper(s.Name) + "_SECRET"))
+}
+
+// Project is the slug every delivery of the source goes to, from
+// HOOKS_<SOURCE>_PROJECT; empty finds the project by repository
+func (s Source) Project() string {
+	return strings.TrimSpace(os.Getenv("HOOKS_" + strings.ToUpper(s.Name) + "_PROJECT"))
+}
+
+// Parse reads a verified delivery
+func (s Source) Parse(r *http.Request, body []byte) (*Event, error) {
+	ev, err := s.parse(r.Header.Get(s.EventHeader), body)
+	if err != nil {
+		return nil, err
+	}
+	if len(ev.Notes) > maxNotes {
+		ev.Notes = strings.ToValidUTF8(ev.Notes[:maxNotes], "") + "\n[truncated]"
+	}
+	return ev, nil
+}
+
+type ghRepo struct {
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func (r ghRepo) urls() []string { return []string{r.HTMLURL, r.CloneURL, r.SSHURL} }
+
+type ghPayload struct {
+	Ref     string `json:"ref"`
+	Action  string `json:"action"`
+	Commits []struct {
+		Message string `json:"message"`
+	} `json:"commits"`
+	Release struct {
+		TagName string `json:"tag_name"`
+		Name    string `json:"name"`
+		Body    string `json:"body"`
+		Draft   bool   `json:"draft"`
+	} `json:"release"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		Merged bool   `json:"merged"`
+	} `json:"pull_request"`
+	Repository ghRepo `json:"repository"`
+}
+
+func parseGitHub(event string, body []byte) (*Event, error) {
+	var p ghPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	ev := &Event{Repo: p.Repository.FullName, RepoURLs: p.Repository.urls()}
+
+	switch event {
+	case "push":
+		messages := make([]string, len(p.Commits))
+		for i, c := range p.Commits {
+			messages[i] = c.Message
+		}
+		notes, err := pushNotes(p.Ref, p.Repository.DefaultBranch, ev.Repo, messages)
+		if err != nil {
+			return nil, err
+		}
+		ev.Kind, ev.Notes = KindPush, notes
+	case "release":
+		if p.Action != "published" || p.Release.Draft {
+			return nil, fmt.Errorf("%w: release %s", ErrIgnored, p.Action)
+		}
+		ev.Kind, ev.Notes = KindRelease, releaseNotes(ev.Repo, p.Release.TagName, p.Release.Name, p.Release.Body)
+	case "pull_request":
+		if p.Action != "closed" || !p.PullRequest.Merged {
+			return nil, fmt.Errorf("%w: pull request %s", ErrIgnored, p.Action)
+		}
+		ev.Kind, ev.Notes = KindMerge, mergeNotes(ev.Repo, "pull request", p.PullRequest.Number, p.PullRequest.Title, p.PullRequest.Body)
+	default:
+		return nil, fmt.Errorf("%w: %q events", ErrIgnored, event)
+	}
+	return ev, nil
+}
+
+type glPayload struct {
+	ObjectKind string `json:"object_kind"`
+	Ref        string `json:"ref"`
+	Commits    []struct {
+		Message string `json:"message"`
+	} `json:"commits"`
+	Action      string `json:"action"` // release hooks
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Attributes  struct {
+		IID         int    `json:"iid"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Action      string `json:"action"`
+	} `json:"object_attributes"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+		HTTPURL           string `json:"git_http_url"`
+		SSHURL            string `json:"git_ssh_url"`
+		DefaultBranch     string `json:"default_branch"`
+	} `json:"project"`
+}
+
+// parseGitLab reads push, release and merge request hooks. The event
+// header ("Push Hook") and object_kind say the same; object_kind is used.
+func parseGitLab(_ string, body []byte) (*Event, error) {
+	var p glPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	ev := &Event{
+		Repo:     p.Project.PathWithNamespace,
+		RepoURLs: []string{p.Project.WebURL, p.Project.HTTPURL, p.Project.SSHURL},
+	}
+
+	switch p.ObjectKind {
+	case "push":
+		messages := make([]string, len(p.Commits))
+		for i, c := range p.Commits {
+			messages[i] = c.Message
+		}
+		notes, err := pushNotes(p.Ref, p.Project.DefaultBranch, ev.Repo, messages)
+		if err != nil {
+			return nil, err
+		}
+		ev.Kind, ev.Notes = KindPush, notes
+	case "release":
+		if p.Action != "create" {
+			return nil, fmt.Errorf("%w: release %s", ErrIgnored, p.Action)
+		}
+		ev.Kind, ev.Notes = KindRelease, releaseNotes(ev.Repo, p.Tag, p.Name, p.Description)
+	case "merge_request":
+		if p.Attributes.Action != "merge" {
+			return nil, fmt.Errorf("%w: merge request %s", ErrIgnored, p.Attributes.Action)
+		}
+		ev.Kind, ev.Notes = KindMerge, mergeNotes(ev.Repo, "merge request", p.Attributes.IID, p.Attributes.Title, p.Attributes.Description)
+	default:
+		return nil, fmt.Errorf("%w: %q events", ErrIgnored, p.ObjectKind)
+	}
+	return ev, nil
+}
+
+type vxPayload struct {
+	Kind    string `json:"kind"`
+	Project string `json:"project"`
+	Repo    string `json:"repo"`
+	RepoURL string `json:"repo_url"`
+	Notes   string `json:"notes"`
+}
+
+// parseVexora reads {"kind", "project", "repo", "repo_url", "notes"}; only
+// notes is required. The project is the signed "project" slug, else the
+// one with repo_url
+func parseVexora(_ string, body []byte) (*Event, error) {
+	var p vxPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Notes) == "" {
+		return nil, errors.New("notes are required")
+	}
+	if p.Kind == "" {
+		p.Kind = KindCI
+	}
+	return &Event{Kind: p.Kind, Repo: p.Repo, RepoURLs: []string{p.RepoURL}, Project: p.Project, Notes: p.Notes}, nil
+}
+
+// pushNotes lists the commit messages of a push to the default branch, or
+// to main or master when the payload doesn't say which that is. Other
+// branches and tags are work in progress, not news.
+func pushNotes(ref, defaultBranch, repo string, messages []string) (string, error) {
+	branch, ok := strings.CutPrefix(ref, "refs/heads/")
+	if !ok {
+		return "", fmt.Errorf("%w: push to %s", ErrIgnored, ref)
+	}
+	branches := []string{defaultBranch}
+	if defaultBranch == "" {
+		branches = []string{"main", "master"}
+	}
+	if !slices.Contains(branches, branch) {
+		return "", fmt.Errorf("%w: push to branch %s", ErrIgnored, branch)
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("%w: push without commits", ErrIgnored)
+	}
+
+	var sb strings.Builder
+	commits := "commits"
+	if len(messages) == 1 {
+		commits = "commit"
+	}
+	fmt.Fprintf(&sb, "Pushed %d %s to %s of %s:\n", len(messages), commits, branch, repo)
+	for i, msg := range messages {
+		if i == maxCommits {
+			fmt.Fprintf(&sb, "\n…and %d more commits\n", len(messages)-maxCommits)
+			break
+		}
+		fmt.Fprintf(&sb, "\n- %s\n", strings.TrimSpace(msg))
+	}
+	return sb.String(), nil
+}
+
+func releaseNotes(repo, tag, name, changelog string) string {
+	title := tag
+	if name != "" && name != tag {
+		title += " (" + name + ")"
+	}
+	return fmt.Sprintf("Released %s of %s.\n\nChangelog:\n%s", title, repo, strings.TrimSpace(changelog))
+}
+
+func mergeNotes(repo, kind string, number int, title, description string) string {
+	notes := fmt.Sprintf("Merged %s #%d into %s: %s", kind, number, repo, title)
+	if description = strings.TrimSpace(description); description != "" {
+		notes += "\n\n" + description
+	}
+	return notes
+}
@@ -0,0 +1,215 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// hookCase is one delivery and what Parse should make of it
+type hookCase struct {
+	name    string
+	event   string // event header
+	body    string
+	kind    string
+	notes   string // exact notes when set
+	ignored bool
+	error   bool
+}
+
+func runHookCases(t *testing.T, source string, tests []hookCase) {
+	t.Helper()
+	s, ok := GetSource(source)
+	if !ok {
+		t.Fatalf("no %s source", source)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/hooks/"+source, strings.NewReader(tt.body))
+			if s.EventHeader != "" {
+				r.Header.Set(s.EventHeader, tt.event)
+			}
+			ev, err := s.Parse(r, []byte(tt.body))
+			switch {
+			case tt.ignored:
+				if !errors.Is(err, ErrIgnored) {
+					t.Fatalf("err = %v, want ErrIgnored", err)
+				}
+				return
+			case tt.error:
+				if err == nil || errors.Is(err, ErrIgnored) {
+					t.Fatalf("err = %v, want a parse error", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Parse: %v", err)
+			}
+			if ev.Kind != tt.kind {
+				t.Errorf("kind = %q, want %q", ev.Kind, tt.kind)
+			}
+			if tt.notes != "" && ev.Notes != tt.notes {
+				t.Errorf("notes = %q, want %q", ev.Notes, tt.notes)
+			}
+		})
+	}
+}
+
+// ghPush is a GitHub push payload
+func ghPush(ref, defaultBranch string, messages ...string) string {
+	commits := make([]string, len(messages))
+	for i, m := range messages {
+		commits[i] = fmt.Sprintf(`{"id": "%040d", "message": %q}`, i, m)
+	}
+	return fmt.Sprintf(`{"ref": %q, "before": "0000", "after": "1111",
+		"commits": [%s],
+		"repository": {"full_name": "acme/app", "html_url": "https://github.com/acme/app",
+			"clone_url": "https://github.com/acme/app.git", "ssh_url": "git@github.com:acme/app.git",
+			"default_branch": %q}}`, ref, strings.Join(commits, ", "), defaultBranch)
+}
+
+func TestParseGitHub(t *testing.T) {
+	release := func(action string, draft bool) string {
+		return fmt.Sprintf(`{"action": %q, "release": {"tag_name": "v2.0.0", "name": "Falcon", "body": "- Faster\n", "draft": %t},
+			"repository": {"full_name": "acme/app"}}`, action, draft)
+	}
+	pr := func(action string, merged bool) string {
+		return fmt.Sprintf(`{"action": %q, "number": 42, "pull_request": {"number": 42, "title": "Add queue", "body": "Closes #7", "merged": %t},
+			"repository": {"full_name": "acme/app"}}`, action, merged)
+	}
+	runHookCases(t, "github", []hookCase{
+		{name: "push to the default branch", event: "push", body: ghPush("refs/heads/trunk", "trunk", "Add queue\n\nLong body", "Fix typo"),
+			kind: KindPush, notes: "Pushed 2 commits to trunk of acme/app:\n\n- Add queue\n\nLong body\n\n- Fix typo\n"},
+		{name: "single commit", event: "push", body: ghPush("refs/heads/main", "main", "Fix typo"),
+			kind: KindPush, notes: "Pushed 1 commit to main of acme/app:\n\n- Fix typo\n"},
+		{name: "push to another branch", event: "push", body: ghPush("refs/heads/feature", "main", "WIP"), ignored: true},
+		{name: "main is not the default branch", event: "push", body: ghPush("refs/heads/main", "trunk", "WIP"), ignored: true},
+		{name: "main without a default branch", event: "push", body: ghPush("refs/heads/main", "", "Fix typo"), kind: KindPush},
+		{name: "master without a default branch", event: "push", body: ghPush("refs/heads/master", "", "Fix typo"), kind: KindPush},
+		{name: "other branch without a default branch", event: "push", body: ghPush("refs/heads/develop", "", "WIP"), ignored: true},
+		{name: "tag push", event: "push", body: ghPush("refs/tags/v2.0.0", "main", "Release"), ignored: true},
+		{name: "branch deleted", event: "push", body: ghPush("refs/heads/main", "main"), ignored: true},
+		{name: "published release", event: "release", body: release("published", false),
+			kind: KindRelease, notes: "Released v2.0.0 (Falcon) of acme/app.\n\nChangelog:\n- Faster"},
+		{name: "draft release", event: "release", body: release("published", true), ignored: true},
+		{name: "created release", event: "release", body: release("created", false), ignored: true},
+		{name: "merged pull request", event: "pull_request", body: pr("closed", true),
+			kind: KindMerge, notes: "Merged pull request #42 into acme/app: Add queue\n\nCloses #7"},
+		{name: "closed without merging", event: "pull_request", body: pr("closed", false), ignored: true},
+		{name: "opened pull request", event: "pull_request", body: pr("opened", false), ignored: true},
+		{name: "ping", event: "ping", body: `{"zen": "Keep it simple."}`, ignored: true},
+		{name: "invalid JSON", event: "push", body: `{"ref": `, error: true},
+	})
+}
+
+func TestParseGitea(t *testing.T) {
+	runHookCases(t, "gitea", []hookCase{
+		{name: "push", event: "push", body: ghPush("refs/heads/main", "main", "Fix typo"), kind: KindPush},
+		{name: "tag push", event: "push", body: ghPush("refs/tags/v1", "main", "Release"), ignored: true},
+	})
+}
+
+func TestParseGitLab(t *testing.T) {
+	project := `"project": {"path_with_namespace": "acme/app", "web_url": "https://gitlab.com/acme/app",
+		"git_http_url": "https://gitlab.com/acme/app.git", "git_ssh_url": "git@gitlab.com:acme/app.git", "default_branch": %q}`
+	push := func(ref, defaultBranch string) string {
+		return fmt.Sprintf(`{"object_kind": "push", "ref": %q, "commits": [{"message": "Fix typo\n"}], `+project+`}`, ref, defaultBranch)
+	}
+	mr := func(action string) string {
+		return fmt.Sprintf(`{"object_kind": "merge_request", "object_attributes": {"iid": 9, "title": "Add queue", "description": "", "action": %q}, `+project+`}`, action, "main")
+	}
+	release := func(action string) string {
+		return fmt.Sprintf(`{"object_kind": "release", "action": %q, "tag": "v2.0.0", "name": "v2.0.0", "description": "- Faster", `+project+`}`, action, "main")
+	}
+	runHookCases(t, "gitlab", []hookCase{
+		{name: "push to the default branch", event: "Push Hook", body: push("refs/heads/main", "main"),
+			kind: KindPush, notes: "Pushed 1 commit to main of acme/app:\n\n- Fix typo\n"},
+		{name: "push to another branch", event: "Push Hook", body: push("refs/heads/feature", "main"), ignored: true},
+		{name: "master without a default branch", event: "Push Hook", body: push("refs/heads/master", ""), kind: KindPush},
+		{name: "tag push", event: "Tag Push Hook", body: `{"object_kind": "tag_push", "ref": "refs/tags/v2.0.0"}`, ignored: true},
+		{name: "tag ref in a push", event: "Push Hook", body: push("refs/tags/v2.0.0", "main"), ignored: true},
+		{name: "created release", event: "Release Hook", body: release("create"),
+			kind: KindRelease, notes: "Released v2.0.0 of acme/app.\n\nChangelog:\n- Faster"},
+		{name: "updated release", event: "Release Hook", body: release("update"), ignored: true},
+		{name: "merged merge request", event: "Merge Request Hook", body: mr("merge"),
+			kind: KindMerge, notes: "Merged merge request #9 into acme/app: Add queue"},
+		{name: "opened merge request", event: "Merge Request Hook", body: mr("open"), ignored: true},
+		{name: "closed merge request", event: "Merge Request Hook", body: mr("close"), ignored: true},
+		{name: "issue", event: "Issue Hook", body: `{"object_kind": "issue"}`, ignored: true},
+		{name: "invalid JSON", event: "Push Hook", body: `[]`, error: true},
+	})
+}
+
+func TestParseVexora(t *testing.T) {
+	runHookCases(t, "vexora", []hookCase{
+		{name: "notes only", body: `{"notes": "Deployed v2 to production"}`, kind: KindCI, notes: "Deployed v2 to production"},
+		{name: "kind given", body: `{"kind": "deploy", "project": "acme", "repo": "acme/app", "repo_url": "https://git.example.com/acme/app", "notes": "Deployed"}`,
+			kind: "deploy", notes: "Deployed"},
+		{name: "blank notes", body: `{"kind": "deploy", "notes": "  "}`, error: true},
+		{name: "no notes", body: `{"project": "acme"}`, error: true},
+		{name: "invalid JSON", body: `notes`, error: true},
+	})
+
+	ev, err := parseVexora("", []byte(`{"project": "acme", "repo": "acme/app", "repo_url": "https://git.example.com/acme/app", "notes": "Deployed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Project != "acme" || ev.Repo != "acme/app" || !slices.Equal(ev.RepoURLs, []string{"https://git.example.com/acme/app"}) {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestPushNotes(t *testing.T) {
+	many := make([]string, maxCommits+5)
+	for i := range many {
+		many[i] = fmt.Sprintf("Commit %d", i+1)
+	}
+	notes, err := pushNotes("refs/heads/main", "main", "acme/app", many)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(notes, fmt.Sprintf("- Commit %d\n", maxCommits)) || strings.Contains(notes, fmt.Sprintf("Commit %d\n", maxCommits+1)) {
+		t.Errorf("notes don't stop after %d commits:\n%s", maxCommits, notes)
+	}
+	if !strings.HasPrefix(notes, "Pushed 25 commits") || !strings.HasSuffix(notes, "\n…and 5 more commits\n") {
+		t.Errorf("notes = %q", notes)
+	}
+}
+
+func TestParseTruncates(t *testing.T) {
+	s, _ := GetSource("vexora")
+	tests := []struct {
+		name  string
+		notes string
+		cut   bool
+	}{
+		{"at the limit", strings.Repeat("a", maxNotes), false},
+		{"over the limit", strings.Repeat("a", maxNotes+1), true},
+		{"multibyte rune at the cut", strings.Repeat("a", maxNotes-1) + "é" + strings.Repeat("b", 10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"notes": %q}`, tt.notes)
+			ev, err := s.Parse(httptest.NewRequest("POST", "/hooks/vexora", nil), []byte(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.cut {
+				if ev.Notes != tt.notes {
+					t.Errorf("notes changed")
+				}
+				return
+			}
+			text, ok := strings.CutSuffix(ev.Notes, "\n[truncated]")
+			if !ok || len(text) > maxNotes {
+				t.Errorf("notes are %d bytes, ending %q", len(ev.Notes), ev.Notes[len(ev.Notes)-20:])
+			}
+			if !utf8.ValidString(ev.Notes) {
+				t.Error("truncation split a rune")
+			}
+		})
+	}
+}
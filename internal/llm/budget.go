@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrBudgetExceeded matches every *BudgetError
+var ErrBudgetExceeded = errors.New("generation budget exceeded")
+
+// BudgetError is returned when a budget with the reject action is used up
+type BudgetError struct {
+	State BudgetState
+}
+
+func (e *BudgetError) Error() string {
+	scope := "global"
+	if e.State.Project != "" {
+		scope = "project " + e.State.Project
+	}
+	var used []string
+	if e.State.MaxTokens > 0 {
+		used = append(used, fmt.Sprintf("%d/%d tokens", e.State.UsedTokens, e.State.MaxTokens))
+	}
+	if e.State.MaxCostUSD > 0 {
+		used = append(used, fmt.Sprintf("$%.4f/$%.4f", e.State.UsedCostUSD, e.State.MaxCostUSD))
+	}
+	return fmt.Sprintf("%s %s budget exceeded (%s), resets at %s", scope, e.State.Period, strings.Join(used, ", "), e.State.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *BudgetError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// ResetsAt is when the budget's period ends
+func (e *BudgetError) ResetsAt() time.Time {
+	return e.State.ResetsAt
+}
+
+type routeKey struct{}
+
+// checkBudget runs before a platform generation. A used-up budget either
+// fails it with *BudgetError or, with the fallback action, pins the
+// returned context to the local Ollama provider. Reject wins when both apply.
+func checkBudget(ctx context.Context, project string) (context.Context, error) {
+	if store == nil {
+		return ctx, nil
+	}
+	budgets, err := store.Budgets(project, time.Now())
+	if err != nil {
+		log.Printf("❌ Budget check failed, allowing generation: %v", err)
+		return ctx, nil
+	}
+
+	var fallback *BudgetState
+	for _, state := range budgets {
+		if !state.Exceeded {
+			continue
+		}
+		if !state.Fallback {
+			return ctx, &BudgetError{State: state}
+		}
+		fallback = &state
+	}
+	if fallback != nil {
+		log.Printf("💸 %s, routing to %s", (&BudgetError{State: *fallback}).Error(), ProviderOllama)
+		return context.WithValue(ctx, routeKey{}, ProviderOllama), nil
+	}
+	return ctx, nil
+}
+
+// CheckBudget reports a used-up budget that would reject the project's
+// generations
+func CheckBudget(project string) error {
+	_, err := checkBudget(context.Background(), project)
+	return err
+}
+
+// routed reports the provider a budget pinned ctx to, if any
+func routed(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(routeKey{}).(string)
+	return name, ok
+}
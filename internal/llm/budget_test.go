@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStore serves fixed budgets and voices and keeps recorded usage
+type fakeStore struct {
+	budgets []BudgetState
+	voices  map[string]*Voice
+	usage   []UsageRecord
+}
+
+func (s *fakeStore) Voice(name string) (*Voice, error) { return s.voices[name], nil }
+
+func (s *fakeStore) ProjectVoice(project string) (*Voice, error) { return nil, nil }
+
+func (s *fakeStore) Budgets(project string, now time.Time) ([]BudgetState, error) {
+	return s.budgets, nil
+}
+
+func (s *fakeStore) RecordUsage(u UsageRecord) error {
+	s.usage = append(s.usage, u)
+	return nil
+}
+
+func useStore(t *testing.T, s Store) {
+	t.Helper()
+	prev := store
+	SetStore(s)
+	t.Cleanup(func() { SetStore(prev) })
+}
+
+func TestCheckBudget(t *testing.T) {
+	resets := time.Now().Add(time.Hour)
+	tests := []struct {
+		name     string
+		budgets  []BudgetState
+		rejected bool
+		routed   bool
+	}{
+		{"no budgets", nil, false, false},
+		{"within budget", []BudgetState{{Period: "daily", MaxTokens: 100, UsedTokens: 10}}, false, false},
+		{"reject", []BudgetState{{Period: "daily", MaxTokens: 100, UsedTokens: 100, Exceeded: true, ResetsAt: resets}}, true, false},
+		{"fallback", []BudgetState{{Period: "daily", Fallback: true, Exceeded: true}}, false, true},
+		{"reject wins", []BudgetState{
+			{Period: "daily", Fallback: true, Exceeded: true},
+			{Period: "monthly", Project: "acme", Exceeded: true, ResetsAt: resets},
+		}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useStore(t, &fakeStore{budgets: tt.budgets})
+			ctx, err := checkBudget(context.Background(), "acme")
+			if got := errors.Is(err, ErrBudgetExceeded); got != tt.rejected {
+				t.Fatalf("rejected = %v (%v), want %v", got, err, tt.rejected)
+			}
+			var be *BudgetError
+			if errors.As(err, &be) && !be.ResetsAt().Equal(resets) {
+				t.Errorf("ResetsAt = %s, want %s", be.ResetsAt(), resets)
+			}
+			if err != nil {
+				return
+			}
+			name, ok := routed(ctx)
+			if ok != tt.routed || (ok && name != ProviderOllama) {
+				t.Errorf("routed = %q, %v, want %v", name, ok, tt.routed)
+			}
+		})
+	}
+}
+
+func TestWithVoice(t *testing.T) {
+	useStore(t, &fakeStore{voices: map[string]*Voice{"acme": {Name: "acme", Version: 2}}})
+
+	in, err := Input{VoiceProfile: "acme"}.withVoice()
+	if err != nil || in.voiceID() != "voice:acme@v2" {
+		t.Fatalf("withVoice = %q, %v", in.voiceID(), err)
+	}
+	if _, err := (Input{VoiceProfile: "nope"}).withVoice(); !errors.Is(err, ErrUnknownVoice) {
+		t.Fatalf("unknown profile: err = %v, want ErrUnknownVoice", err)
+	}
+}
@@ -2,11 +2,15 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -14,6 +18,7 @@ type geminiReq struct {
 	SystemInstruction *geminiInstruction `json:"system_instruction,omitempty"`
 	Contents          []geminiContent    `json:"contents"`
 	GenerationConfig  *geminiConfig      `json:"generationConfig,omitempty"`
+	SafetySettings    []geminiSafety     `json:"safetySettings,omitempty"`
 }
 
 type geminiInstruction struct {
@@ -30,31 +35,246 @@ type geminiPart struct {
 }
 
 type geminiConfig struct {
-	ResponseMimeType string `json:"response_mime_type,omitempty"`
+	ResponseMimeType string   `json:"response_mime_type,omitempty"`
+	ResponseSchema   *Schema  `json:"responseSchema,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	TopK             int      `json:"topK,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	CandidateCount   int      `json:"candidateCount,omitempty"`
+}
+
+type geminiSafety struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
 }
 
 type geminiResp struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
+	Candidates     []geminiCandidate `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason   string         `json:"blockReason"`
+		SafetyRatings []geminiRating `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
 
-func callGemini(sysPrompt, userMsg, format string) (string, error) {
+type geminiCandidate struct {
+	Content struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"content"`
+	FinishReason  string         `json:"finishReason"`
+	SafetyRatings []geminiRating `json:"safetyRatings"`
+}
+
+type geminiRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+// Finish reasons that mean a safety filter stopped the reply
+var geminiBlockReasons = []string{"SAFETY", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY"}
+
+// Harm categories and thresholds accepted by ParseSafetySettings
+var (
+	geminiHarmCategories = []string{"HARASSMENT", "HATE_SPEECH", "SEXUALLY_EXPLICIT", "DANGEROUS_CONTENT", "CIVIC_INTEGRITY"}
+	geminiThresholds     = []string{"BLOCK_NONE", "BLOCK_ONLY_HIGH", "BLOCK_MEDIUM_AND_ABOVE", "BLOCK_LOW_AND_ABOVE", "OFF"}
+)
+
+// text returns the first candidate that finished normally. A blocked
+// prompt or a reply cut short comes back as *BlockedError or *FinishError.
+func (r *geminiResp) text() (string, error) {
+	if r.PromptFeedback != nil && r.PromptFeedback.BlockReason != "" {
+		return "", &BlockedError{Provider: ProviderGemini, Reason: r.PromptFeedback.BlockReason,
+			Prompt: true, Categories: flaggedCategories(r.PromptFeedback.SafetyRatings)}
+	}
+	if len(r.Candidates) == 0 {
+		return "", fmt.Errorf("empty response from gemini")
+	}
+	for _, c := range r.Candidates {
+		if c.FinishReason != "" && c.FinishReason != "STOP" {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range c.Content.Parts {
+			text.WriteString(part.Text)
+		}
+		if text.Len() > 0 {
+			return text.String(), nil
+		}
+	}
+	return "", finishError(r.Candidates[0])
+}
+
+// usage counts thinking tokens as completion tokens, they are billed as output
+func (r *geminiResp) usage() Usage {
+	if r.UsageMetadata == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount + r.UsageMetadata.ThoughtsTokenCount,
+	}
+}
+
+func finishError(c geminiCandidate) error {
+	if slices.Contains(geminiBlockReasons, c.FinishReason) {
+		return &BlockedError{Provider: ProviderGemini, Reason: c.FinishReason, Categories: flaggedCategories(c.SafetyRatings)}
+	}
+	if c.FinishReason == "" || c.FinishReason == "STOP" {
+		return fmt.Errorf("empty response from gemini")
+	}
+	return &FinishError{Provider: ProviderGemini, Reason: c.FinishReason}
+}
+
+func flaggedCategories(ratings []geminiRating) []string {
+	var out []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability == "HIGH" || r.Probability == "MEDIUM" {
+			out = append(out, strings.TrimPrefix(r.Category, "HARM_CATEGORY_"))
+		}
+	}
+	return out
+}
+
+type geminiProvider struct{}
+
+func init() {
+	Register(geminiProvider{})
+}
+
+func (geminiProvider) Name() string { return ProviderGemini }
+
+func (geminiProvider) Generate(ctx context.Context, sysPrompt, userMsg, format string, opts Options) (Result, error) {
+	req, model, err := newGeminiRequest(ctx, "generateContent", sysPrompt, userMsg, format, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("❌ Gemini Connection Error: %v", err)
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	var gResp geminiResp
+	if err := json.NewDecoder(resp.Body).Decode(&gResp); err != nil {
+		log.Printf("❌ Gemini JSON Decode Error: %v", err)
+		return Result{}, err
+	}
+
+	if gResp.Error != nil {
+		log.Printf("❌ Gemini API Error: %s", gResp.Error.Message)
+		return Result{}, fmt.Errorf("gemini error: %s", gResp.Error.Message)
+	}
+
+	text, err := gResp.text()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Text:     text,
+		Provider: ProviderGemini,
+		Model:    model,
+		Usage:    gResp.usage(),
+	}, nil
+}
+
+// Stream uses streamGenerateContent with alt=sse so every chunk arrives as a "data:" event.
+func (geminiProvider) Stream(ctx context.Context, sysPrompt, userMsg, format string, opts Options, onToken func(string) error) (Result, error) {
+	req, model, err := newGeminiRequest(ctx, "streamGenerateContent", sysPrompt, userMsg, format, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// No client timeout: the stream lives as long as ctx does
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("❌ Gemini Connection Error: %v", err)
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var gResp geminiResp
+		if json.NewDecoder(resp.Body).Decode(&gResp) == nil && gResp.Error != nil {
+			return Result{}, fmt.Errorf("gemini error: %s", gResp.Error.Message)
+		}
+		return Result{}, fmt.Errorf("gemini status: %s", resp.Status)
+	}
+
+	// Only the first candidate is streamed; its last chunk carries the finish reason
+	var text strings.Builder
+	var last geminiCandidate
+	var usage Usage // every chunk carries the running total
+	err = readSSE(resp.Body, func(data string) error {
+		var chunk geminiResp
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("gemini error: %s", chunk.Error.Message)
+		}
+		if chunk.UsageMetadata != nil {
+			usage = chunk.usage()
+		}
+		if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+			_, err := chunk.text()
+			return err
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		if chunk.Candidates[0].FinishReason != "" {
+			last = chunk.Candidates[0]
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.Text == "" {
+				continue
+			}
+			text.WriteString(part.Text)
+			if err := onToken(part.Text); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if last.FinishReason != "" && last.FinishReason != "STOP" {
+		return Result{}, finishError(last)
+	}
+
+	return Result{Text: text.String(), Provider: ProviderGemini, Model: model, Usage: usage}, nil
+}
+
+// newGeminiRequest builds the REST call for the given method
+// (generateContent or streamGenerateContent) and returns the model used.
+func newGeminiRequest(ctx context.Context, method, sysPrompt, userMsg, format string, opts Options) (*http.Request, string, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY environment variable not set")
+		return nil, "", fmt.Errorf("GEMINI_API_KEY environment variable not set")
 	}
 
-	model := os.Getenv("GEMINI_MODEL")
-	model = "gemini-2.5-flash-lite"
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, apiKey)
+	model := geminiModel(opts)
+	base := strings.TrimRight(envOr("GEMINI_BASE_URL", DefaultGeminiURL), "/")
+	url := fmt.Sprintf("%s/models/%s:%s?key=%s", base, model, method, apiKey)
+	if method == "streamGenerateContent" {
+		url += "&alt=sse"
+	}
 
 	reqBody := geminiReq{
 		Contents: []geminiContent{
@@ -73,45 +293,89 @@ func callGemini(sysPrompt, userMsg, format string) (string, error) {
 		}
 	}
 
+	cfg := geminiConfig{
+		Temperature:     opts.Temperature,
+		TopP:            opts.TopP,
+		TopK:            opts.TopK,
+		MaxOutputTokens: opts.MaxTokens,
+		StopSequences:   opts.Stop,
+		CandidateCount:  opts.CandidateCount,
+	}
 	if format == "json" {
-		reqBody.GenerationConfig = &geminiConfig{
-			ResponseMimeType: "application/json",
+		cfg.ResponseMimeType = "application/json"
+		if opts.Schema != nil {
+			cfg.ResponseSchema = opts.Schema.gemini()
 		}
 	}
+	reqBody.GenerationConfig = &cfg
+	reqBody.SafetySettings = geminiSafetySettings(opts.Safety)
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	return req, model, nil
+}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("❌ Gemini Connection Error: %v", err)
-		return "", err
+// geminiModel is opts.Model, else GEMINI_MODEL_<PLATFORM> (e.g.
+// GEMINI_MODEL_NEWSLETTER), else GEMINI_MODEL, else DefaultGeminiModel.
+func geminiModel(opts Options) string {
+	if opts.Model != "" {
+		return opts.Model
 	}
-	defer resp.Body.Close()
-
-	var gResp geminiResp
-	if err := json.NewDecoder(resp.Body).Decode(&gResp); err != nil {
-		log.Printf("❌ Gemini JSON Decode Error: %v", err)
-		return "", err
+	if opts.Platform != "" {
+		if m := strings.TrimSpace(os.Getenv("GEMINI_MODEL_" + strings.ToUpper(opts.Platform))); m != "" {
+			return m
+		}
 	}
+	return envOr("GEMINI_MODEL", DefaultGeminiModel)
+}
 
-	if gResp.Error != nil {
-		log.Printf("❌ Gemini API Error: %s", gResp.Error.Message)
-		return "", fmt.Errorf("gemini error: %s", gResp.Error.Message)
+// geminiSafetySettings merges GEMINI_SAFETY_SETTINGS
+// ("HARASSMENT=BLOCK_ONLY_HIGH,DANGEROUS_CONTENT=BLOCK_NONE") with the
+// per-request overrides. Nothing configured keeps Gemini's defaults.
+func geminiSafetySettings(overrides map[string]string) []geminiSafety {
+	merged, err := ParseSafetySettings(strings.Split(os.Getenv("GEMINI_SAFETY_SETTINGS"), ","))
+	if err != nil {
+		log.Printf("⚠️ Ignoring GEMINI_SAFETY_SETTINGS: %v", err)
+		merged = map[string]string{}
+	}
+	for category, threshold := range overrides {
+		merged[category] = threshold
 	}
 
-	if len(gResp.Candidates) == 0 || len(gResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from gemini")
+	settings := make([]geminiSafety, 0, len(merged))
+	for category, threshold := range merged {
+		settings = append(settings, geminiSafety{Category: category, Threshold: threshold})
 	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Category < settings[j].Category })
+	return settings
+}
 
-	return gResp.Candidates[0].Content.Parts[0].Text, nil
+// ParseSafetySettings reads "CATEGORY=THRESHOLD" pairs into Options.Safety.
+// Categories may leave out the HARM_CATEGORY_ prefix; empty pairs are skipped.
+func ParseSafetySettings(pairs []string) (map[string]string, error) {
+	settings := map[string]string{}
+	for _, pair := range pairs {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		category, threshold, ok := strings.Cut(pair, "=")
+		category = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(category)), "HARM_CATEGORY_")
+		threshold = strings.ToUpper(strings.TrimSpace(threshold))
+		if !ok || !slices.Contains(geminiHarmCategories, category) {
+			return nil, fmt.Errorf("unknown safety category in %q (want one of %s)", pair, strings.Join(geminiHarmCategories, ", "))
+		}
+		if !slices.Contains(geminiThresholds, threshold) {
+			return nil, fmt.Errorf("unknown safety threshold in %q (want one of %s)", pair, strings.Join(geminiThresholds, ", "))
+		}
+		settings["HARM_CATEGORY_"+category] = threshold
+	}
+	return settings, nil
 }
@@ -0,0 +1,321 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OpenAIConfig describes any server speaking the OpenAI chat completions
+// protocol: Ollama, llama.cpp server, vLLM, LM Studio or a hosted API.
+type OpenAIConfig struct {
+	Name         string            // registry name, e.g. "ollama" or "vllm"
+	BaseURL      string            // e.g. http://localhost:11434/v1
+	Model        string            // default model when Options.Model is empty
+	APIKey       string            // optional
+	APIKeyHeader string            // defaults to "Authorization" (sent as "Bearer <key>")
+	Headers      map[string]string // extra headers sent on every request
+	Timeout      time.Duration     // defaults to 120s
+}
+
+type openAIProvider struct {
+	cfg OpenAIConfig
+}
+
+// NewOpenAICompatible builds a provider for an OpenAI-compatible server.
+// Register the result to make it selectable through LLM_PROVIDER.
+func NewOpenAICompatible(cfg OpenAIConfig) Provider {
+	if cfg.APIKeyHeader == "" {
+		cfg.APIKeyHeader = "Authorization"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 120 * time.Second
+	}
+	return &openAIProvider{cfg: cfg}
+}
+
+// OpenAIConfigFromEnv reads <PREFIX>_BASE_URL, <PREFIX>_MODEL, <PREFIX>_API_KEY,
+// <PREFIX>_API_KEY_HEADER and <PREFIX>_HEADERS ("Key=Value,Key2=Value2").
+func OpenAIConfigFromEnv(name, prefix, defaultURL, defaultModel string) OpenAIConfig {
+	cfg := OpenAIConfig{
+		Name:         name,
+		BaseURL:      envOr(prefix+"_BASE_URL", defaultURL),
+		Model:        envOr(prefix+"_MODEL", defaultModel),
+		APIKey:       os.Getenv(prefix + "_API_KEY"),
+		APIKeyHeader: os.Getenv(prefix + "_API_KEY_HEADER"),
+		Headers:      parseHeaders(os.Getenv(prefix + "_HEADERS")),
+	}
+	return cfg
+}
+
+func init() {
+	Register(NewOpenAICompatible(OpenAIConfigFromEnv(ProviderOllama, "OLLAMA", DefaultOllamaURL, DefaultOllamaModel)))
+	Register(NewOpenAICompatible(OpenAIConfigFromEnv(ProviderOpenAI, "OPENAI", DefaultOpenAIURL, "")))
+
+	// Additional local servers: LLM_OPENAI_COMPAT=vllm,lmstudio reads VLLM_* and LMSTUDIO_*
+	for _, name := range strings.Split(os.Getenv("LLM_OPENAI_COMPAT"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+		Register(NewOpenAICompatible(OpenAIConfigFromEnv(name, prefix, "", "")))
+	}
+}
+
+type chatReq struct {
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	TopP           *float64        `json:"top_p,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string  `json:"name"`
+	Schema *Schema `json:"schema"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"` // only in the last chunk, with include_usage
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type chatResp struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *openAIProvider) Name() string { return p.cfg.Name }
+
+func (p *openAIProvider) Generate(ctx context.Context, sysPrompt, userMsg, format string, opts Options) (Result, error) {
+	req, model, err := p.newRequest(ctx, sysPrompt, userMsg, format, opts, false)
+	if err != nil {
+		return Result{}, err
+	}
+
+	client := &http.Client{Timeout: p.cfg.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("❌ %s Connection Error: %v", p.cfg.Name, err)
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := p.checkStatus(resp); err != nil {
+		return Result{}, err
+	}
+
+	var cResp chatResp
+	if err := json.NewDecoder(resp.Body).Decode(&cResp); err != nil {
+		return Result{}, err
+	}
+	if cResp.Error != nil {
+		return Result{}, fmt.Errorf("%s error: %s", p.cfg.Name, cResp.Error.Message)
+	}
+	if len(cResp.Choices) == 0 {
+		return Result{}, fmt.Errorf("empty response from %s", p.cfg.Name)
+	}
+
+	return Result{
+		Text:     cResp.Choices[0].Message.Content,
+		Provider: p.cfg.Name,
+		Model:    model,
+		Usage:    cResp.Usage.usage(),
+	}, nil
+}
+
+// Stream sends stream: true and forwards each delta from the SSE response.
+func (p *openAIProvider) Stream(ctx context.Context, sysPrompt, userMsg, format string, opts Options, onToken func(string) error) (Result, error) {
+	req, model, err := p.newRequest(ctx, sysPrompt, userMsg, format, opts, true)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// No client timeout: the stream lives as long as ctx does
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("❌ %s Connection Error: %v", p.cfg.Name, err)
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := p.checkStatus(resp); err != nil {
+		return Result{}, err
+	}
+
+	var text strings.Builder
+	var usage Usage
+	err = readSSE(resp.Body, func(data string) error {
+		if data == "[DONE]" {
+			return errStreamDone
+		}
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s error: %s", p.cfg.Name, chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		return onToken(chunk.Choices[0].Delta.Content)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Text: text.String(), Provider: p.cfg.Name, Model: model, Usage: usage}, nil
+}
+
+func (p *openAIProvider) newRequest(ctx context.Context, sysPrompt, userMsg, format string, opts Options, stream bool) (*http.Request, string, error) {
+	model := p.cfg.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if p.cfg.BaseURL == "" || model == "" {
+		return nil, "", fmt.Errorf("%s: base URL and model must be configured", p.cfg.Name)
+	}
+
+	reqBody := chatReq{
+		Model:       model,
+		Stream:      stream,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		MaxTokens:   opts.MaxTokens,
+		Stop:        opts.Stop,
+		Messages: []message{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: userMsg},
+		},
+	}
+	if stream {
+		reqBody.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if format == "json" {
+		reqBody.ResponseFormat = &responseFormat{Type: "json_object"}
+		// Ollama turns this into its native format schema
+		if opts.Schema != nil {
+			reqBody.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "output", Schema: opts.Schema}}
+		}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, "", err
+	}
+	p.setHeaders(req)
+	return req, model, nil
+}
+
+func (u *chatUsage) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+func (p *openAIProvider) checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	log.Printf("❌ %s returned non-200 status: %s", p.cfg.Name, resp.Status)
+	return fmt.Errorf("%s status: %s: %s", p.cfg.Name, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// endpoint accepts base URLs with or without the /chat/completions suffix.
+func (p *openAIProvider) endpoint() string {
+	base := strings.TrimRight(p.cfg.BaseURL, "/")
+	if strings.HasSuffix(base, "/chat/completions") {
+		return base
+	}
+	return base + "/chat/completions"
+}
+
+func (p *openAIProvider) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if p.cfg.APIKey == "" {
+		return
+	}
+	if strings.EqualFold(p.cfg.APIKeyHeader, "Authorization") {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	} else {
+		req.Header.Set(p.cfg.APIKeyHeader, p.cfg.APIKey)
+	}
+}
+
+func parseHeaders(raw string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
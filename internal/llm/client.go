@@ -1,46 +1,42 @@
 package llm
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
-	// CONFIG: Local Llama (Ollama)
-	OllamaURL = "http://localhost:11434/v1/chat/completions"
-	Model     = "gemma3"
+	// CONFIG: Local Llama (Ollama) defaults, overridable via OLLAMA_BASE_URL / OLLAMA_MODEL
+	DefaultOllamaURL   = "http://localhost:11434/v1"
+	DefaultOllamaModel = "gemma3"
+
+	// CONFIG: Hosted OpenAI-style API defaults, overridable via OPENAI_BASE_URL / OPENAI_MODEL
+	DefaultOpenAIURL = "https://api.openai.com/v1"
+
+	// CONFIG: Gemini defaults, overridable via GEMINI_BASE_URL and GEMINI_MODEL / GEMINI_MODEL_<PLATFORM>
+	DefaultGeminiURL   = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultGeminiModel = "gemini-2.5-flash-lite"
 
 	ProviderOllama = "ollama"
 	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
 )
 
+// getProvider returns the provider name configured through LLM_PROVIDER,
+// defaulting to Gemini. Unknown names fall back to Ollama.
 func getProvider() string {
-	p := os.Getenv("LLM_PROVIDER")
+	p := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER")))
 	if p == "" {
-		return "gemini"
+		return ProviderGemini
 	}
-	return "gemini"
-}
-
-type ollamaReq struct {
-	Model    string    `json:"model"`
-	Messages []message `json:"messages"`
-	Format   string    `json:"format,omitempty"` // "json" or empty
-	Stream   bool      `json:"stream"`
-}
-
-type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type ollamaResp struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
+	if _, err := GetProvider(p); err != nil {
+		log.Printf("⚠️ Unknown provider '%s', falling back to Ollama", p)
+		return ProviderOllama
+	}
+	return p
 }
 
 const (
@@ -50,41 +46,35 @@ const (
 	TypeNewsletter = "newsletter"
 )
 
-// Unified Entry Point
-func GenerateContent(feedType, userNotes string) (string, error) {
-	switch feedType {
-	case TypeTwitter:
-		return genTwitter(userNotes)
-	case TypeLinkedIn:
-		return genLinkedIn(userNotes)
-	case TypeInstagram:
-		return genInstagram(userNotes)
-	case TypeNewsletter:
-		return genNewsletter(userNotes)
-	default:
-		return "", fmt.Errorf("unsupported feed type: %s", feedType)
+// Unified Entry Point. Zero opts use the provider defaults. The voice
+// profile of the request or project is composed into the system prompt,
+// and outputs breaking the platform's rules are repaired (see validated).
+// Used-up budgets fail the call or route it to Ollama (see checkBudget).
+func GenerateContent(feedType string, in Input, opts Options) (Result, error) {
+	p, err := getPlatform(feedType)
+	if err != nil {
+		return Result{}, err
 	}
-}
-
-func callLLM(sysPrompt, userMsg, format string) (string, error) {
-	provider := getProvider()
-	log.Printf("🤖 Using LLM Provider: %s", provider)
-
-	var res string
-	var err error
-
-	switch provider {
-	case ProviderGemini:
-		res, err = callGemini(sysPrompt, userMsg, format)
-	case ProviderOllama:
-		res, err = callOllama(sysPrompt, userMsg, format)
-	default:
-		log.Printf("⚠️ Unknown provider '%s', falling back to Ollama", provider)
-		res, err = callOllama(sysPrompt, userMsg, format)
+	if in, err = in.withVoice(); err != nil {
+		return Result{}, err
 	}
 
+	ctx, err := checkBudget(context.Background(), in.Project)
 	if err != nil {
-		log.Printf("❌ LLM Error (%s): %v", provider, err)
+		return Result{}, err
+	}
+	if _, ok := routed(ctx); ok {
+		opts.Model = "" // the requested model belongs to another provider
+	}
+	opts.Platform = feedType
+	ctx, usage := withMeter(ctx, in.Project, feedType)
+	start := time.Now()
+	res, err := p.Generate(ctx, in, opts)
+	if err == nil {
+		res = validated(ctx, p, in, opts, res)
 	}
+	res.Latency = time.Since(start)
+	res.Usage = usage.total()
+	res.Params = opts
 	return res, err
 }
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoProviderAvailable is returned when every provider in the chain
+// either failed or had its circuit open.
+var ErrNoProviderAvailable = errors.New("no llm provider available")
+
+const (
+	BreakerClosed   = "closed"    // healthy, requests flow
+	BreakerOpen     = "open"      // tripped, requests skip this provider
+	BreakerHalfOpen = "half-open" // cooldown elapsed, next request is a probe
+)
+
+// ProviderHealth is a snapshot of a provider's circuit breaker.
+type ProviderHealth struct {
+	Provider            string    `json:"provider"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure"`
+	LastSuccess         time.Time `json:"last_success"`
+	OpenUntil           time.Time `json:"open_until"`
+}
+
+type breaker struct {
+	mu      sync.Mutex
+	health  ProviderHealth
+	probing bool // a half-open probe is in flight
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+func getBreaker(name string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[name]
+	if !ok {
+		b = &breaker{health: ProviderHealth{Provider: name, State: BreakerClosed}}
+		breakers[name] = b
+	}
+	return b
+}
+
+// allow reports whether a request may be sent to the provider. An open
+// breaker whose cooldown has elapsed moves to half-open and lets one probe
+// through; other requests are refused until the probe succeeds or fails.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.health.State {
+	case BreakerOpen:
+		if now.Before(b.health.OpenUntil) {
+			return false
+		}
+		b.health.State = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// release ends a probe that says nothing about the provider's health (the
+// caller gave up, or the content was refused) so the next request can probe
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) success(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.health.State = BreakerClosed
+	b.probing = false
+	b.health.ConsecutiveFailures = 0
+	b.health.LastSuccess = now
+	b.health.OpenUntil = time.Time{}
+}
+
+func (b *breaker) failure(now time.Time, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.health.ConsecutiveFailures++
+	b.health.LastFailure = now
+	b.health.LastError = err.Error()
+	b.probing = false
+
+	// A failed probe re-opens immediately; otherwise trip at the threshold.
+	if b.health.State == BreakerHalfOpen || b.health.ConsecutiveFailures >= breakerThreshold() {
+		b.health.State = BreakerOpen
+		b.health.OpenUntil = now.Add(breakerCooldown(err))
+		log.Printf("🔌 Circuit opened for %s until %s", b.health.Provider, b.health.OpenUntil.Format(time.RFC3339))
+	}
+}
+
+func (b *breaker) snapshot() ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.health
+}
+
+// Health returns the breaker state of every provider in the configured chain.
+func Health() []ProviderHealth {
+	chain := providerChain()
+	out := make([]ProviderHealth, 0, len(chain))
+	for _, name := range chain {
+		out = append(out, getBreaker(name).snapshot())
+	}
+	return out
+}
+
+// chainFor is the provider chain, or only the provider a budget routed
+// ctx to (see checkBudget)
+func chainFor(ctx context.Context) []string {
+	if name, ok := routed(ctx); ok {
+		return []string{name}
+	}
+	return providerChain()
+}
+
+// providerChain is LLM_PROVIDER followed by the comma-separated LLM_FALLBACK
+// list, e.g. LLM_PROVIDER=gemini LLM_FALLBACK=ollama.
+func providerChain() []string {
+	chain := []string{getProvider()}
+	seen := map[string]bool{chain[0]: true}
+	for _, name := range strings.Split(os.Getenv("LLM_FALLBACK"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+// generate walks the provider chain until one succeeds.
+func generate(ctx context.Context, sysPrompt, userMsg, format string, opts Options) (Result, error) {
+	var errs []error
+	for _, name := range chainFor(ctx) {
+		provider, err := GetProvider(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		b := getBreaker(name)
+		if !b.allow(time.Now()) {
+			log.Printf("⏭️ Skipping %s: circuit open", name)
+			errs = append(errs, fmt.Errorf("%s: circuit open", name))
+			continue
+		}
+
+		log.Printf("🤖 Using LLM Provider: %s", name)
+		start := time.Now()
+		res, err := provider.Generate(ctx, sysPrompt, userMsg, format, opts)
+		if err == nil && strings.TrimSpace(res.Text) == "" {
+			err = fmt.Errorf("empty response from %s", name)
+		}
+		recordUsage(ctx, name, opts, &res, time.Since(start), err)
+		if err != nil {
+			log.Printf("❌ LLM Error (%s): %v", name, err)
+			if ctx.Err() != nil {
+				// Caller gave up; don't blame the provider or try the next one.
+				b.release()
+				return Result{}, ctx.Err()
+			}
+			if isContentError(err) {
+				b.release()
+			} else {
+				b.failure(time.Now(), err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		b.success(time.Now())
+		return res, nil
+	}
+	return Result{}, fmt.Errorf("%w: %w", ErrNoProviderAvailable, errors.Join(errs...))
+}
+
+func breakerThreshold() int {
+	if n, err := strconv.Atoi(os.Getenv("LLM_BREAKER_THRESHOLD")); err == nil && n > 0 {
+		return n
+	}
+	return 3
+}
+
+// breakerCooldown is LLM_BREAKER_COOLDOWN (default 60s); rate limits
+// keep the circuit open five times longer.
+func breakerCooldown(err error) time.Duration {
+	cooldown := 60 * time.Second
+	if d, perr := time.ParseDuration(os.Getenv("LLM_BREAKER_COOLDOWN")); perr == nil && d > 0 {
+		cooldown = d
+	}
+	if msg := strings.ToLower(err.Error()); strings.Contains(msg, "429") || strings.Contains(msg, "rate limit") || strings.Contains(msg, "quota") {
+		cooldown *= 5
+	}
+	return cooldown
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	t.Setenv("LLM_BREAKER_THRESHOLD", "1")
+	t.Setenv("LLM_BREAKER_COOLDOWN", "1m")
+	now := time.Now()
+	b := &breaker{health: ProviderHealth{Provider: "test", State: BreakerClosed}}
+
+	b.failure(now, errors.New("boom"))
+	if b.allow(now) {
+		t.Fatal("open breaker allowed a request before the cooldown")
+	}
+
+	later := now.Add(2 * time.Minute)
+	if !b.allow(later) {
+		t.Fatal("breaker refused the probe after the cooldown")
+	}
+	for i := 0; i < 3; i++ {
+		if b.allow(later) {
+			t.Fatal("half-open breaker allowed a second request while probing")
+		}
+	}
+
+	// A probe that says nothing about health lets the next caller probe
+	b.release()
+	if !b.allow(later) {
+		t.Fatal("breaker refused a new probe after release")
+	}
+
+	// A failed probe re-opens the circuit
+	b.failure(later, errors.New("still down"))
+	if got := b.snapshot().State; got != BreakerOpen {
+		t.Fatalf("state after failed probe = %s, want %s", got, BreakerOpen)
+	}
+
+	// A successful probe closes it and lets everyone through
+	again := later.Add(2 * time.Minute)
+	if !b.allow(again) {
+		t.Fatal("breaker refused the second probe")
+	}
+	b.success(again)
+	for i := 0; i < 3; i++ {
+		if !b.allow(again) {
+			t.Fatal("closed breaker refused a request")
+		}
+	}
+}
@@ -1,93 +1,103 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"strings"
+	"vexora-studio/internal/prompts"
 )
 
-// --- Platform Specific Logic ---
-
-func genTwitter(notes string) (string, error) {
-	return fetchText(PromptTwitter, notes)
+func init() {
+	RegisterPlatform(Platform{Name: TypeTwitter, Generate: genTwitter, Stream: streamTwitter,
+		Validate: validateTwitter, Repair: repairTwitter})
+	RegisterPlatform(Platform{Name: TypeLinkedIn, Generate: structuredGenerator[LinkedInPost](prompts.LinkedIn), Stream: structuredStreamer[LinkedInPost](prompts.LinkedIn),
+		Validate: validateLinkedIn, Repair: structuredRepairer[LinkedInPost](prompts.LinkedIn)})
+	RegisterPlatform(Platform{Name: TypeInstagram, Generate: structuredGenerator[InstagramPost](prompts.Instagram), Stream: structuredStreamer[InstagramPost](prompts.Instagram),
+		Validate: validateInstagram, Repair: structuredRepairer[InstagramPost](prompts.Instagram)})
+	RegisterPlatform(Platform{Name: TypeNewsletter, Generate: genNewsletter, Stream: streamNewsletter,
+		Validate: validateNewsletter, Repair: repairNewsletter})
 }
 
-func genLinkedIn(notes string) (string, error) {
-	return fetchText(PromptLinkedIn, notes)
-}
-
-func genInstagram(notes string) (string, error) {
-	return fetchText(PromptInstagram, notes)
-}
-
-type NewsletterMeta struct {
-	Subject string   `json:"subject_line"`
-	Preview string   `json:"preview_text"`
-	Tags    []string `json:"tags"` // Go can now handle the array
-}
+// --- Platform Specific Logic ---
 
-func genNewsletter(notes string) (string, error) {
-	// --- Step A: Fetch Metadata (using specific struct) ---
-	// We can't use fetchJSON here because it returns map[string]string
-	// We invoke callOllama directly with "json" format
-	rawMeta, err := callOllama(PromptNewsMeta, notes, "json")
+func genNewsletter(ctx context.Context, in Input, opts Options) (Result, error) {
+	sys, version, err := in.render(prompts.NewsletterMeta, prompts.NewsletterBody)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	var meta NewsletterMeta
-	// Clean the JSON (remove potential markdown blocks) and Unmarshal
-	if err := json.Unmarshal([]byte(cleanJSON(rawMeta)), &meta); err != nil {
-		return "", fmt.Errorf("meta parse failed: %w", err)
+	// --- Step A: Fetch Metadata (using specific struct) ---
+	meta, err := newsletterMeta(ctx, sys[0], in.Notes, opts)
+	if err != nil {
+		return Result{}, err
 	}
 
 	// --- Step B: Fetch Body (Raw Text) ---
-	bodyText, err := fetchText(PromptNewsBody, notes)
+	body, err := fetchText(ctx, sys[1], in.Notes, opts)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
 	// --- Step C: Combine into Final JSON ---
+	res, err := newsletterResult(meta, body)
+	res.PromptVersion = version
+	return res, err
+}
+
+// repairNewsletter only regenerates the metadata: the rules are about the
+// subject and preview, the body is kept as is
+func repairNewsletter(ctx context.Context, in Input, opts Options, prev Result, violations []Violation) (Result, error) {
+	var nl struct {
+		NewsletterMeta
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(prev.Text), &nl); err != nil {
+		return Result{}, err
+	}
+	sys, _, err := in.render(prompts.NewsletterMeta)
+	if err != nil {
+		return Result{}, err
+	}
+	draft, _ := json.Marshal(nl.NewsletterMeta)
+	meta, err := newsletterMeta(ctx, sys[0], repairMessage(in.Notes, string(draft), violations), opts)
+	if err != nil {
+		return Result{}, err
+	}
+	prev.Text = nl.Body
+	return newsletterResult(meta, prev)
+}
+
+func newsletterMeta(ctx context.Context, sysPrompt, notes string, opts Options) (NewsletterMeta, error) {
+	var meta NewsletterMeta
+	_, err := GenerateStructured(ctx, sysPrompt, notes, SchemaFor(meta), opts, &meta)
+	return meta, err
+}
+
+func newsletterResult(meta NewsletterMeta, body Result) (Result, error) {
 	// We convert the Tags array to a string for the final output if needed,
 	// or keep it as an array if your frontend supports it.
 	finalOutput := map[string]interface{}{
 		"subject_line": meta.Subject,
 		"preview_text": meta.Preview,
 		"tags":         meta.Tags, // This stays an array now!
-		"body":         bodyText,
+		"body":         body.Text,
 	}
 
 	jsonBytes, err := json.Marshal(finalOutput)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
-	return string(jsonBytes), nil
-}
 
-func fetchJSON(sysPrompt, userMsg string) (map[string]string, error) {
-	raw, err := callLLM(sysPrompt, userMsg, "json")
-	if err != nil {
-		return nil, err
-	}
-
-	clean := cleanJSON(raw)
-	var result map[string]string
-	if err := json.Unmarshal([]byte(clean), &result); err != nil {
-		return nil, fmt.Errorf("json parse failed: %w", err)
+	// The body is the bulk of the edition, so credit its provider
+	body.Text = string(jsonBytes)
+	body.Metadata = map[string]any{
+		"subject_line": meta.Subject,
+		"preview_text": meta.Preview,
+		"tags":         meta.Tags,
 	}
-	return result, nil
+	return body, nil
 }
 
 // fetchText calls the selected LLM for raw text generation (Markdown, etc)
-func fetchText(sysPrompt, userMsg string) (string, error) {
-	return callLLM(sysPrompt, userMsg, "")
-}
-
-func cleanJSON(input string) string {
-	start := strings.Index(input, "{")
-	end := strings.LastIndex(input, "}")
-	if start == -1 || end == -1 {
-		return "{}"
-	}
-	return input[start : end+1]
+func fetchText(ctx context.Context, sysPrompt, userMsg string, opts Options) (Result, error) {
+	return generate(ctx, sysPrompt, userMsg, "", opts)
 }
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"vexora-studio/internal/prompts"
+)
+
+// Input is what a feed is generated from. Everything but Notes is optional.
+// Voice and Audience override the voice profile's tone and audience.
+type Input struct {
+	Notes        string
+	Project      string
+	VoiceProfile string // empty uses the project's profile, if any
+	Voice        string
+	Audience     string
+	Hashtags     []string
+	Thread       *Thread // twitter only
+
+	profile *Voice // set by withVoice
+}
+
+func (in Input) promptData() prompts.Data {
+	d := prompts.Data{Project: in.Project, Voice: in.Voice, Audience: in.Audience, Hashtags: in.Hashtags}
+	if t := in.Thread; t != nil {
+		d.Tweets, d.CTA = t.Length, t.CTA
+	}
+	if v := in.profile; v != nil {
+		d.Footer = v.Signature
+		if d.Voice == "" {
+			d.Voice = v.Tone
+		}
+		if d.Audience == "" {
+			d.Audience = v.Audience
+		}
+	}
+	return d
+}
+
+// render resolves and executes the named prompt templates for in and adds
+// the voice profile rules. The returned version joins the IDs of every
+// template used and the profile, e.g.
+// "newsletter_meta@v2+newsletter_body@builtin-1a2b3c4d+voice:acme@v3".
+func (in Input) render(names ...string) ([]string, string, error) {
+	texts := make([]string, len(names))
+	ids := make([]string, len(names))
+	for i, name := range names {
+		var err error
+		if texts[i], ids[i], err = prompts.Render(name, in.promptData()); err != nil {
+			return nil, "", err
+		}
+		texts[i] += in.voiceRules(name)
+	}
+	if id := in.voiceID(); id != "" {
+		ids = append(ids, id)
+	}
+	return texts, strings.Join(ids, "+"), nil
+}
+
+// Platform is a content target (twitter, linkedin, ...). Registering one
+// makes it available to GenerateContent, StreamContent, the job queue and
+// the REST routes.
+//
+// Implementations set Result.PromptVersion to the templates they rendered.
+// Outputs that fail Validate are sent back through Repair a bounded number
+// of times (see validate.go).
+type Platform struct {
+	Name     string
+	Generate func(ctx context.Context, in Input, opts Options) (Result, error)
+	// Stream is optional; without it StreamContent emits the full text at once
+	Stream func(ctx context.Context, in Input, opts Options, onToken func(string) error) (Result, error)
+	// Validate is optional and lists the platform rules an output breaks
+	Validate func(res Result) []Violation
+	// Repair is optional and asks the model to fix prev (see validated)
+	Repair func(ctx context.Context, in Input, opts Options, prev Result, violations []Violation) (Result, error)
+}
+
+var (
+	platformsMu sync.RWMutex
+	platforms   = map[string]Platform{}
+)
+
+// RegisterPlatform adds or replaces a platform
+func RegisterPlatform(p Platform) {
+	platformsMu.Lock()
+	defer platformsMu.Unlock()
+	platforms[p.Name] = p
+}
+
+func getPlatform(name string) (Platform, error) {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+	p, ok := platforms[name]
+	if !ok {
+		return Platform{}, fmt.Errorf("unsupported feed type: %s", name)
+	}
+	return p, nil
+}
+
+// PlatformNames lists every registered platform, sorted
+func PlatformNames() []string {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func IsFeedType(feedType string) bool {
+	_, err := getPlatform(feedType)
+	return err == nil
+}
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Typed replies of the structured platforms. Generate and Stream ask the
+// model for these, store the fields in Result.Metadata and the composed
+// post, as published, in Result.Text. Streaming sends the JSON as it
+// arrives (see structuredStreamer).
+
+// Tweet is a single tweet
+type Tweet struct {
+	Text string `json:"text" desc:"The complete tweet, hashtags and footer included" schema:"minLength=1"`
+}
+
+// LinkedInPost is a LinkedIn post split into its parts
+type LinkedInPost struct {
+	Hook     string   `json:"hook" desc:"The one-line opening statement" schema:"minLength=1"`
+	Body     string   `json:"body" desc:"Everything after the hook, footer included, without hashtags" schema:"minLength=1"`
+	Hashtags []string `json:"hashtags" desc:"Hashtags appended at the very end, each starting with #" schema:"maxItems=5"`
+}
+
+// InstagramPost is an Instagram caption with its hashtag wall and image description
+type InstagramPost struct {
+	Caption  string   `json:"caption" desc:"The caption, footer included, without the hashtag wall" schema:"minLength=1"`
+	Hashtags []string `json:"hashtags" desc:"The hashtag wall, each starting with #" schema:"maxItems=30"`
+	AltText  string   `json:"alt_text" desc:"Alt text describing the screenshot for screen readers" schema:"minLength=1,maxLength=1000"`
+}
+
+// NewsletterMeta is the envelope of a newsletter edition; the body is markdown
+type NewsletterMeta struct {
+	Subject string   `json:"subject_line" desc:"Email subject line"`
+	Preview string   `json:"preview_text" desc:"Inbox preview text shown after the subject"`
+	Tags    []string `json:"tags" desc:"Topic tags for the archive"`
+}
+
+// ThreadReply is the model's side of a twitter thread, before numbering
+type ThreadReply struct {
+	Tweets []string `json:"tweets" desc:"The tweets in order: hook first, call to action last" schema:"minItems=1,maxItems=25"`
+}
+
+// Compose tidies the reply and renders the post as published
+func (t *Tweet) Compose() string {
+	t.Text = strings.TrimSpace(t.Text)
+	return t.Text
+}
+
+func (p *LinkedInPost) Compose() string {
+	p.Hashtags = hashtagList(p.Hashtags)
+	return joinParts(p.Hook, p.Body, strings.Join(p.Hashtags, " "))
+}
+
+func (p *InstagramPost) Compose() string {
+	p.Hashtags = hashtagList(p.Hashtags)
+	return joinParts(p.Caption, strings.Join(p.Hashtags, " "))
+}
+
+// post is implemented by the pointer to a typed reply
+type post[T any] interface {
+	*T
+	Compose() string
+}
+
+// structuredGenerator generates a typed post from a single prompt template
+func structuredGenerator[T any, P post[T]](template string) func(context.Context, Input, Options) (Result, error) {
+	return func(ctx context.Context, in Input, opts Options) (Result, error) {
+		sys, version, err := in.render(template)
+		if err != nil {
+			return Result{}, err
+		}
+		res, err := fetchPost[T, P](ctx, sys[0], in.Notes, opts)
+		res.PromptVersion = version
+		return res, err
+	}
+}
+
+// structuredRepairer re-prompts a single typed template with the violations
+func structuredRepairer[T any, P post[T]](template string) func(context.Context, Input, Options, Result, []Violation) (Result, error) {
+	return func(ctx context.Context, in Input, opts Options, prev Result, violations []Violation) (Result, error) {
+		sys, _, err := in.render(template)
+		if err != nil {
+			return Result{}, err
+		}
+		return fetchPost[T, P](ctx, sys[0], repairMessage(in.Notes, prev.Text, violations), opts)
+	}
+}
+
+func fetchPost[T any, P post[T]](ctx context.Context, sysPrompt, userMsg string, opts Options) (Result, error) {
+	var p T
+	res, err := GenerateStructured(ctx, sysPrompt, userMsg, SchemaFor(p), opts, &p)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Text = P(&p).Compose()
+	res.Metadata, err = fieldsOf(p)
+	return res, err
+}
+
+// fieldsOf turns a typed reply into metadata fields
+func fieldsOf(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	return fields, json.Unmarshal(raw, &fields)
+}
+
+// hashtagList trims the tags and adds the # the model sometimes leaves out
+func hashtagList(tags []string) []string {
+	out := []string{}
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(t), "")
+		if t == "" || t == "#" {
+			continue
+		}
+		if !strings.HasPrefix(t, "#") {
+			t = "#" + t
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func joinParts(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options carries per-call generation settings. Zero values mean
+// "use the provider default".
+type Options struct {
+	Model          string            `json:"model,omitempty"`
+	Temperature    *float64          `json:"temperature,omitempty"`
+	TopP           *float64          `json:"top_p,omitempty"`
+	TopK           int               `json:"top_k,omitempty"`
+	MaxTokens      int               `json:"max_tokens,omitempty"`
+	Stop           []string          `json:"stop,omitempty"`            // stop sequences
+	CandidateCount int               `json:"candidate_count,omitempty"` // Gemini only; the first complete candidate wins
+	Safety         map[string]string `json:"safety,omitempty"`          // Gemini harm category -> threshold, see ParseSafetySettings
+
+	// Platform being generated, set by GenerateContent/StreamContent so
+	// providers can pick a per-platform model
+	Platform string `json:"-"`
+
+	// Schema constrains a "json" format reply (see GenerateStructured)
+	Schema *Schema `json:"-"`
+}
+
+// Result is what a provider hands back for a single generation.
+type Result struct {
+	Text     string
+	Provider string
+	Model    string
+	Usage    Usage // tokens of this call; GenerateContent sums every call of the feed
+
+	// Set by GenerateContent/StreamContent for a whole platform generation
+	Latency       time.Duration
+	Metadata      map[string]any // structured fields, e.g. a newsletter's subject line
+	PromptVersion string         // identifies the system prompts used
+	Params        Options        // generation settings the caller asked for
+	Validation    *ValidationReport
+}
+
+// ErrContentBlocked matches every *BlockedError
+var ErrContentBlocked = errors.New("content blocked by safety filters")
+
+// BlockedError is returned when a provider's safety filters blocked the
+// prompt or the reply.
+type BlockedError struct {
+	Provider   string
+	Reason     string   // e.g. SAFETY, PROHIBITED_CONTENT
+	Prompt     bool     // the prompt was blocked, not the reply
+	Categories []string // harm categories that triggered the block
+}
+
+func (e *BlockedError) Error() string {
+	what := "reply"
+	if e.Prompt {
+		what = "prompt"
+	}
+	msg := fmt.Sprintf("%s blocked the %s: %s", e.Provider, what, e.Reason)
+	if len(e.Categories) > 0 {
+		msg += " (" + strings.Join(e.Categories, ", ") + ")"
+	}
+	return msg
+}
+
+func (e *BlockedError) Is(target error) bool { return target == ErrContentBlocked }
+
+// FinishError is returned when a reply ended for another reason than
+// completing normally, e.g. MAX_TOKENS or RECITATION.
+type FinishError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *FinishError) Error() string {
+	return fmt.Sprintf("%s stopped before finishing: %s", e.Provider, e.Reason)
+}
+
+// isContentError reports errors caused by the request itself rather than
+// the provider's health; they don't count against its circuit breaker
+func isContentError(err error) bool {
+	var fe *FinishError
+	return errors.Is(err, ErrContentBlocked) || errors.As(err, &fe)
+}
+
+// Provider is implemented by every LLM backend (Ollama, Gemini, ...).
+// format is either "" for free text or "json" for a JSON object, matching
+// opts.Schema when the provider supports it.
+type Provider interface {
+	Name() string
+	Generate(ctx context.Context, sysPrompt, userMsg, format string, opts Options) (Result, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Provider{}
+)
+
+// Register makes a provider selectable through LLM_PROVIDER.
+// Registering the same name twice replaces the previous provider.
+func Register(p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(p.Name())] = p
+}
+
+// GetProvider looks up a registered provider by name.
+func GetProvider(name string) (Provider, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown llm provider: %s", name)
+	}
+	return p, nil
+}
+
+// Providers lists the names of all registered providers.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StreamProvider is implemented by providers that can emit text as it is
+// generated. onToken is called for every chunk; returning an error aborts
+// the stream.
+type StreamProvider interface {
+	Provider
+	Stream(ctx context.Context, sysPrompt, userMsg, format string, opts Options, onToken func(string) error) (Result, error)
+}
@@ -0,0 +1,279 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema every provider understands: objects,
+// arrays, strings, numbers, booleans and enums. Build one from a Go struct
+// with SchemaFor or from a JSON Schema document with ParseSchema.
+type Schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// ParseSchema reads a JSON Schema document. Keywords outside the supported
+// subset are rejected rather than silently ignored.
+func ParseSchema(raw []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var s Schema
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	return &s, s.check("")
+}
+
+func (s *Schema) check(path string) error {
+	switch s.Type {
+	case "object":
+		for _, name := range s.Required {
+			if s.Properties[name] == nil {
+				return fmt.Errorf("schema%s: required property %q is not defined", path, name)
+			}
+		}
+		for name, p := range s.Properties {
+			if err := p.check(path + "." + name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		if s.Items == nil {
+			return fmt.Errorf("schema%s: array needs items", path)
+		}
+		return s.Items.check(path + "[]")
+	case "string", "integer", "number", "boolean":
+	default:
+		return fmt.Errorf("schema%s: unsupported type %q", path, s.Type)
+	}
+	return nil
+}
+
+// SchemaFor derives a schema from a Go struct. Fields follow their json
+// tags; fields without omitempty are required and unknown properties are
+// not allowed. Two extra tags refine a field:
+//
+//	desc:"The hook line"               description shown to the model
+//	schema:"maxLength=60,maxItems=30"  minLength, maxLength, minItems, maxItems
+//	schema:"enum=a|b|c"                allowed string values
+func SchemaFor(v any) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: new(bool)}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if f.Anonymous && name == "" {
+				embedded := schemaOf(f.Type)
+				for n, p := range embedded.Properties {
+					s.Properties[n] = p
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			p := schemaOf(f.Type)
+			p.Description = f.Tag.Get("desc")
+			applyTag(p, f.Tag.Get("schema"))
+			s.Properties[name] = p
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	panic("llm: no schema for " + t.String())
+}
+
+func applyTag(s *Schema, tag string) {
+	for _, kv := range strings.Split(tag, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if k == "enum" {
+			s.Enum = strings.Split(v, "|")
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "minLength":
+			s.MinLength = &n
+		case "maxLength":
+			s.MaxLength = &n
+		case "minItems":
+			s.MinItems = &n
+		case "maxItems":
+			s.MaxItems = &n
+		}
+	}
+}
+
+// String is the compact JSON form, as put in prompts
+func (s *Schema) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Validate decodes data and checks it against the schema. Every problem is
+// reported, with its path, so the model can fix them all in one go.
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("not valid JSON: %w", err)
+	}
+	if dec.More() {
+		return errors.New("not valid JSON: trailing data after the object")
+	}
+	var problems []string
+	s.validate("$", v, &problems)
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v any, problems *[]string) {
+	fail := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			p, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fail("unknown property %q", k)
+				}
+				continue
+			}
+			p.validate(path+"."+k, obj[k], problems)
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("expected an array")
+			return
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			fail("has %d items, needs at least %d", len(arr), *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			fail("has %d items, allows at most %d", len(arr), *s.MaxItems)
+		}
+		for i, item := range arr {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("expected a string")
+			return
+		}
+		n := utf8.RuneCountInString(str)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("is %d characters, needs at least %d", n, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("is %d characters, allows at most %d", n, *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			fail("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			fail("expected an integer")
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			fail("expected a number")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected a boolean")
+		}
+	}
+}
+
+// gemini copies the schema without the keywords Gemini's responseSchema
+// rejects (additionalProperties, minLength, maxLength). Validate still
+// enforces them on the reply.
+func (s *Schema) gemini() *Schema {
+	c := *s
+	c.AdditionalProperties, c.MinLength, c.MaxLength = nil, nil, nil
+	if s.Items != nil {
+		c.Items = s.Items.gemini()
+	}
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for k, p := range s.Properties {
+			c.Properties[k] = p.gemini()
+		}
+	}
+	return &c
+}
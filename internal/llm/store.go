@@ -0,0 +1,74 @@
+package llm
+
+import "time"
+
+// Store is where generations read voice profiles and budgets and record
+// their usage. The api package provides the database-backed one
+// (api.LLMStore) and main installs it with SetStore. Without a store no
+// voice profile applies, budgets aren't enforced and usage isn't recorded.
+type Store interface {
+	// Voice returns the named profile, nil when there is none
+	Voice(name string) (*Voice, error)
+	// ProjectVoice returns the profile attached to project, nil when none is
+	ProjectVoice(project string) (*Voice, error)
+	// Budgets returns the budgets applying to project with the usage of
+	// their current period
+	Budgets(project string, now time.Time) ([]BudgetState, error)
+	// RecordUsage writes one call to the usage ledger
+	RecordUsage(u UsageRecord) error
+}
+
+var store Store
+
+// SetStore installs s; call it once at startup, before any generation
+func SetStore(s Store) {
+	store = s
+}
+
+// Voice is a brand voice profile, composed into the system prompt
+type Voice struct {
+	Name        string
+	Version     int
+	Persona     string // who is writing, e.g. "a Staff Software Engineer"
+	Tone        string
+	Audience    string
+	Emoji       string // none, light or heavy; empty leaves it to the prompt
+	Signature   string
+	BannedWords []string
+	Examples    []VoiceExample
+}
+
+// VoiceExample is a past post used as a few-shot sample. An empty Platform
+// applies to every platform.
+type VoiceExample struct {
+	Platform string
+	Text     string
+}
+
+// BudgetState is a budget with the usage of its current period. Zero
+// limits are unlimited.
+type BudgetState struct {
+	Project     string // empty for the global budget
+	Period      string // daily or monthly
+	Fallback    bool   // route to Ollama once used up instead of rejecting
+	MaxTokens   int
+	MaxCostUSD  float64
+	UsedTokens  int
+	UsedCostUSD float64
+	Exceeded    bool
+	ResetsAt    time.Time
+}
+
+// UsageRecord is one LLM call in the usage ledger
+type UsageRecord struct {
+	Project          string
+	Platform         string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+	CostUSD          float64
+	Status           string // UsageOK, UsageFailed, UsageBlocked or UsageCancelled
+	Error            string // why a call that isn't ok failed
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"vexora-studio/internal/prompts"
+)
+
+// errStreamDone lets an SSE callback end the read loop early without failing.
+var errStreamDone = errors.New("stream done")
+
+// readSSE calls fn with the payload of every "data:" line in a Server-Sent Events body.
+func readSSE(r io.Reader, fn func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+		if err := fn(data); err != nil {
+			if errors.Is(err, errStreamDone) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// StreamContent is the streaming counterpart of GenerateContent. onToken
+// receives text as it arrives; the returned Result holds the final feed,
+// formatted exactly like GenerateContent would have returned it. Repairs
+// are not streamed, so the final text can differ from the tokens sent.
+// Cancelling ctx stops the upstream request.
+func StreamContent(ctx context.Context, feedType string, in Input, opts Options, onToken func(string) error) (Result, error) {
+	p, err := getPlatform(feedType)
+	if err != nil {
+		return Result{}, err
+	}
+	if in, err = in.withVoice(); err != nil {
+		return Result{}, err
+	}
+
+	if ctx, err = checkBudget(ctx, in.Project); err != nil {
+		return Result{}, err
+	}
+	if _, ok := routed(ctx); ok {
+		opts.Model = "" // the requested model belongs to another provider
+	}
+	opts.Platform = feedType
+	ctx, usage := withMeter(ctx, in.Project, feedType)
+	start := time.Now()
+	var res Result
+	if p.Stream != nil {
+		res, err = p.Stream(ctx, in, opts, onToken)
+	} else {
+		res, err = p.Generate(ctx, in, opts)
+		if err == nil {
+			err = onToken(res.Text)
+		}
+	}
+	if err == nil {
+		res = validated(ctx, p, in, opts, res)
+	}
+	res.Latency = time.Since(start)
+	res.Usage = usage.total()
+	res.Params = opts
+	return res, err
+}
+
+// structuredStreamer streams the JSON reply of a typed post as it arrives
+// and decodes it once complete. A reply that doesn't match the schema is
+// sent back with its errors without streaming, like GenerateStructured does.
+func structuredStreamer[T any, P post[T]](template string) func(context.Context, Input, Options, func(string) error) (Result, error) {
+	return func(ctx context.Context, in Input, opts Options, onToken func(string) error) (Result, error) {
+		sys, version, err := in.render(template)
+		if err != nil {
+			return Result{}, err
+		}
+		var p T
+		schema := SchemaFor(p)
+		jsonOpts := opts
+		jsonOpts.Schema = schema
+		res, err := streamGenerate(ctx, schemaPrompt(sys[0], schema), in.Notes, "json", jsonOpts, onToken)
+		if err != nil {
+			return Result{}, err
+		}
+		if err := decodeStrict(res.Text, schema, &p); err != nil {
+			log.Printf("🧩 %s streamed reply does not match the schema: %v", res.Provider, err)
+			res, err = fetchPost[T, P](ctx, sys[0], rejectedMessage(in.Notes, res.Text, err), opts)
+			res.PromptVersion = version
+			return res, err
+		}
+		res.Text = P(&p).Compose()
+		res.Metadata, err = fieldsOf(p)
+		res.PromptVersion = version
+		return res, err
+	}
+}
+
+// streamNewsletter fetches the metadata first; it is a small JSON object,
+// only the body is worth streaming
+func streamNewsletter(ctx context.Context, in Input, opts Options, onToken func(string) error) (Result, error) {
+	sys, version, err := in.render(prompts.NewsletterMeta, prompts.NewsletterBody)
+	if err != nil {
+		return Result{}, err
+	}
+	meta, err := newsletterMeta(ctx, sys[0], in.Notes, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	body, err := streamGenerate(ctx, sys[1], in.Notes, "", opts, onToken)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := newsletterResult(meta, body)
+	res.PromptVersion = version
+	return res, err
+}
+
+// streamGenerate walks the provider chain like generate. Once a provider
+// has emitted text we can't fall back anymore, so later errors are final.
+func streamGenerate(ctx context.Context, sysPrompt, userMsg, format string, opts Options, onToken func(string) error) (Result, error) {
+	var errs []error
+	for _, name := range chainFor(ctx) {
+		provider, err := GetProvider(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		b := getBreaker(name)
+		if !b.allow(time.Now()) {
+			log.Printf("⏭️ Skipping %s: circuit open", name)
+			errs = append(errs, fmt.Errorf("%s: circuit open", name))
+			continue
+		}
+
+		log.Printf("🌊 Streaming from LLM Provider: %s", name)
+		emitted := false
+		emit := func(tok string) error {
+			emitted = true
+			return onToken(tok)
+		}
+
+		start := time.Now()
+		var res Result
+		if sp, ok := provider.(StreamProvider); ok {
+			res, err = sp.Stream(ctx, sysPrompt, userMsg, format, opts, emit)
+		} else {
+			res, err = provider.Generate(ctx, sysPrompt, userMsg, format, opts)
+			if err == nil {
+				err = emit(res.Text)
+			}
+		}
+		if err == nil && strings.TrimSpace(res.Text) == "" {
+			err = fmt.Errorf("empty response from %s", name)
+		}
+		recordUsage(ctx, name, opts, &res, time.Since(start), err)
+		if err != nil {
+			log.Printf("❌ LLM Stream Error (%s): %v", name, err)
+			if ctx.Err() != nil {
+				b.release()
+				return Result{}, ctx.Err()
+			}
+			if isContentError(err) {
+				b.release()
+			} else {
+				b.failure(time.Now(), err)
+			}
+			if emitted {
+				return Result{}, fmt.Errorf("%s: stream interrupted: %w", name, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		b.success(time.Now())
+		return res, nil
+	}
+	return Result{}, fmt.Errorf("%w: %w", ErrNoProviderAvailable, errors.Join(errs...))
+}
@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedProvider replies with its replies in order, repeating the last
+type scriptedProvider struct {
+	name    string
+	replies []string
+
+	mu    sync.Mutex
+	calls []scriptedCall
+}
+
+type scriptedCall struct {
+	SysPrompt, UserMsg, Format string
+	Schema                     *Schema
+}
+
+func (p *scriptedProvider) Name() string { return p.name }
+
+func (p *scriptedProvider) Generate(ctx context.Context, sysPrompt, userMsg, format string, opts Options) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reply := p.replies[min(len(p.calls), len(p.replies)-1)]
+	p.calls = append(p.calls, scriptedCall{sysPrompt, userMsg, format, opts.Schema})
+	return Result{Text: reply, Provider: p.name, Model: "scripted"}, nil
+}
+
+// Stream emits the reply a few bytes at a time
+func (p *scriptedProvider) Stream(ctx context.Context, sysPrompt, userMsg, format string, opts Options, onToken func(string) error) (Result, error) {
+	res, err := p.Generate(ctx, sysPrompt, userMsg, format, opts)
+	for rest := res.Text; rest != "" && err == nil; {
+		n := min(len(rest), 7)
+		err, rest = onToken(rest[:n]), rest[n:]
+	}
+	return res, err
+}
+
+// useProvider makes p the only provider for the rest of the test
+func useProvider(t *testing.T, p *scriptedProvider) {
+	t.Helper()
+	p.name = "scripted-" + strings.ReplaceAll(strings.ToLower(t.Name()), "/", "-")
+	Register(p)
+	t.Setenv("LLM_PROVIDER", p.name)
+	t.Setenv("LLM_FALLBACK", "")
+}
+
+func TestStructuredStreaming(t *testing.T) {
+	linkedIn := `{"hook": "We shipped it.", "body": "Two years of work.", "hashtags": ["golang", "#sqlite"]}`
+	tests := []struct {
+		name     string
+		platform string
+		replies  []string
+		text     string
+		metadata string // one metadata field that must be set
+		calls    int
+	}{
+		{"linkedin", TypeLinkedIn, []string{linkedIn},
+			"We shipped it.\n\nTwo years of work.\n\n#golang #sqlite", "hook", 1},
+		{"instagram", TypeInstagram, []string{`{"caption": "New release", "hashtags": ["dev"], "alt_text": "A terminal"}`},
+			"New release\n\n#dev", "alt_text", 1},
+		{"tweet", TypeTwitter, []string{`{"text": " Shipped it. #golang #sqlite "}`}, "Shipped it. #golang #sqlite", "text", 1},
+		{"invalid reply is retried", TypeLinkedIn, []string{`{"hook": "We shipped it."}`, linkedIn},
+			"We shipped it.\n\nTwo years of work.\n\n#golang #sqlite", "body", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &scriptedProvider{replies: tt.replies}
+			useProvider(t, p)
+
+			var streamed strings.Builder
+			res, err := StreamContent(context.Background(), tt.platform, Input{Notes: "release notes"}, Options{},
+				func(tok string) error { streamed.WriteString(tok); return nil })
+			if err != nil {
+				t.Fatalf("StreamContent: %v", err)
+			}
+			if res.Text != tt.text {
+				t.Errorf("Text = %q, want %q", res.Text, tt.text)
+			}
+			if res.Metadata[tt.metadata] == nil {
+				t.Errorf("Metadata = %v, missing %s", res.Metadata, tt.metadata)
+			}
+			if streamed.String() != tt.replies[0] {
+				t.Errorf("streamed %q, want the JSON reply", streamed.String())
+			}
+			if len(p.calls) != tt.calls {
+				t.Fatalf("%d calls, want %d", len(p.calls), tt.calls)
+			}
+			first := p.calls[0]
+			if first.Format != "json" || first.Schema == nil || !strings.Contains(first.SysPrompt, "# Response Schema") {
+				t.Errorf("stream asked for format %q, schema %v", first.Format, first.Schema)
+			}
+			if tt.calls > 1 && !strings.Contains(p.calls[1].UserMsg, "# Your previous reply was rejected") {
+				t.Errorf("retry didn't send the errors back: %q", p.calls[1].UserMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidStructuredOutput is returned when the model keeps replying with
+// JSON that doesn't match the schema
+var ErrInvalidStructuredOutput = errors.New("llm reply does not match the schema")
+
+// GenerateStructured asks for a JSON reply matching schema and decodes it
+// into out (a pointer to a struct, or to map[string]any for a schema given
+// as JSON Schema). The schema is sent to providers that enforce it
+// (Gemini responseSchema, json_schema response_format for Ollama and other
+// OpenAI-compatible servers) and spelled out in the system prompt for the
+// rest. Replies are validated strictly; invalid ones are sent back with the
+// errors up to LLM_JSON_RETRIES times (default 2).
+func GenerateStructured(ctx context.Context, sysPrompt, userMsg string, schema *Schema, opts Options, out any) (Result, error) {
+	sysPrompt = schemaPrompt(sysPrompt, schema)
+	opts.Schema = schema
+
+	msg := userMsg
+	var lastErr error
+	for attempt := 0; attempt <= jsonRetries(); attempt++ {
+		res, err := generate(ctx, sysPrompt, msg, "json", opts)
+		if err != nil {
+			return Result{}, err
+		}
+		if lastErr = decodeStrict(res.Text, schema, out); lastErr == nil {
+			return res, nil
+		}
+		log.Printf("🧩 %s reply does not match the schema (attempt %d): %v", res.Provider, attempt+1, lastErr)
+		msg = rejectedMessage(userMsg, res.Text, lastErr)
+	}
+	return Result{}, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, lastErr)
+}
+
+// schemaPrompt spells the schema out for providers that don't enforce it
+func schemaPrompt(sysPrompt string, schema *Schema) string {
+	return sysPrompt + "\n\n# Response Schema\nReply with ONLY a JSON object matching this JSON Schema. It replaces any output format described above.\n" + schema.String()
+}
+
+// rejectedMessage sends an invalid reply back with what is wrong with it
+func rejectedMessage(userMsg, reply string, err error) string {
+	return userMsg + "\n\n# Your previous reply was rejected\n" + err.Error() +
+		"\nReply again with ONLY the corrected JSON object.\n\nPrevious reply:\n" + reply
+}
+
+// decodeStrict accepts the JSON object on its own, or wrapped in a single
+// markdown code fence, and nothing else
+func decodeStrict(text string, schema *Schema, out any) error {
+	text = strings.TrimSpace(text)
+	if fenced, ok := strings.CutPrefix(text, "```"); ok {
+		fenced = strings.TrimPrefix(fenced, "json")
+		if body, ok := strings.CutSuffix(fenced, "```"); ok {
+			text = strings.TrimSpace(body)
+		}
+	}
+	if err := schema.Validate([]byte(text)); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(text)))
+	if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(out)
+}
+
+func jsonRetries() int {
+	if n, err := strconv.Atoi(os.Getenv("LLM_JSON_RETRIES")); err == nil && n >= 0 {
+		return n
+	}
+	return 2
+}
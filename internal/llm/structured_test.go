@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        any
+		required []string
+		check    func(t *testing.T, s *Schema)
+	}{
+		{"tweet", Tweet{}, []string{"text"}, func(t *testing.T, s *Schema) {
+			if p := s.Properties["text"]; p.Type != "string" || p.MinLength == nil || *p.MinLength != 1 {
+				t.Errorf("text = %s, want a string with minLength 1", p)
+			}
+		}},
+		{"linkedin", LinkedInPost{}, []string{"hook", "body", "hashtags"}, func(t *testing.T, s *Schema) {
+			p := s.Properties["hashtags"]
+			if p.Type != "array" || p.Items.Type != "string" || p.MaxItems == nil || *p.MaxItems != 5 {
+				t.Errorf("hashtags = %s, want an array of strings with maxItems 5", p)
+			}
+			if s.Properties["hook"].Description == "" {
+				t.Error("hook has no description")
+			}
+		}},
+		{"instagram", InstagramPost{}, []string{"caption", "hashtags", "alt_text"}, func(t *testing.T, s *Schema) {
+			p := s.Properties["alt_text"]
+			if p.MinLength == nil || *p.MinLength != 1 || p.MaxLength == nil || *p.MaxLength != 1000 {
+				t.Errorf("alt_text = %s, want minLength 1 and maxLength 1000", p)
+			}
+		}},
+		{"newsletter", NewsletterMeta{}, []string{"subject_line", "preview_text", "tags"}, func(t *testing.T, s *Schema) {
+			if _, ok := s.Properties["Subject"]; ok {
+				t.Error("Subject is not named after its json tag")
+			}
+		}},
+		{"thread", ThreadReply{}, []string{"tweets"}, func(t *testing.T, s *Schema) {
+			p := s.Properties["tweets"]
+			if p.MinItems == nil || *p.MinItems != 1 || p.MaxItems == nil || *p.MaxItems != 25 {
+				t.Errorf("tweets = %s, want minItems 1 and maxItems 25", p)
+			}
+		}},
+		{"pointer", &Tweet{}, []string{"text"}, func(t *testing.T, s *Schema) {}},
+		{"omitempty is optional", struct {
+			A string `json:"a"`
+			B string `json:"b,omitempty"`
+			C string `json:"-"`
+			d string
+		}{}, []string{"a"}, func(t *testing.T, s *Schema) {
+			if len(s.Properties) != 2 {
+				t.Errorf("properties = %v, want a and b", s.Properties)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SchemaFor(tt.v)
+			if s.Type != "object" {
+				t.Fatalf("type = %q, want object", s.Type)
+			}
+			if s.AdditionalProperties == nil || *s.AdditionalProperties {
+				t.Error("additional properties are allowed")
+			}
+			if !slices.Equal(s.Required, tt.required) {
+				t.Errorf("required = %v, want %v", s.Required, tt.required)
+			}
+			if err := s.check(""); err != nil {
+				t.Errorf("check: %v", err)
+			}
+			tt.check(t, s)
+		})
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		want  string // hook after decoding
+		error string // substring of the error, "" if valid
+	}{
+		{"valid", `{"hook": "Hi", "body": "There", "hashtags": []}`, "Hi", ""},
+		{"code fence", "```json\n{\"hook\": \"Hi\", \"body\": \"There\", \"hashtags\": []}\n```", "Hi", ""},
+		{"bare fence", "```\n{\"hook\": \"Hi\", \"body\": \"There\", \"hashtags\": []}\n```", "Hi", ""},
+		{"unknown field", `{"hook": "Hi", "body": "There", "hashtags": [], "cta": "Follow"}`, "", `unknown property "cta"`},
+		{"missing field", `{"hook": "Hi", "hashtags": []}`, "", `missing required property "body"`},
+		{"empty string", `{"hook": "", "body": "There", "hashtags": []}`, "", "$.hook: is 0 characters"},
+		{"too many items", `{"hook": "Hi", "body": "There", "hashtags": ["a", "b", "c", "d", "e", "f"]}`, "", "allows at most 5"},
+		{"wrong type", `{"hook": "Hi", "body": "There", "hashtags": "#a"}`, "", "$.hashtags: expected an array"},
+		{"prose around it", `Sure! {"hook": "Hi", "body": "There", "hashtags": []}`, "", "not valid JSON"},
+		{"trailing data", `{"hook": "Hi", "body": "There", "hashtags": []} {}`, "", "trailing data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p LinkedInPost
+			err := decodeStrict(tt.text, SchemaFor(p), &p)
+			if tt.error == "" {
+				if err != nil {
+					t.Fatalf("decodeStrict: %v", err)
+				}
+				if p.Hook != tt.want {
+					t.Errorf("hook = %q, want %q", p.Hook, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("error = %v, want one containing %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestGenerateStructured(t *testing.T) {
+	valid := `{"text": "Shipped it."}`
+	tests := []struct {
+		name    string
+		retries string
+		replies []string
+		calls   int
+		invalid bool
+	}{
+		{"first reply valid", "", []string{valid}, 1, false},
+		{"retry then succeed", "", []string{`{"txt": "Shipped it."}`, valid}, 2, false},
+		{"retries exhausted", "", []string{`{}`}, 3, true},
+		{"retries configured", "4", []string{`{}`, `{}`, `{}`, `{}`, valid}, 5, false},
+		{"no retries", "0", []string{`{}`, valid}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_JSON_RETRIES", tt.retries)
+			p := &scriptedProvider{replies: tt.replies}
+			useProvider(t, p)
+
+			var out Tweet
+			_, err := GenerateStructured(context.Background(), "Write a tweet.", "notes", SchemaFor(out), Options{}, &out)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidStructuredOutput) {
+					t.Fatalf("error = %v, want ErrInvalidStructuredOutput", err)
+				}
+			} else if err != nil {
+				t.Fatalf("GenerateStructured: %v", err)
+			} else if out.Text != "Shipped it." {
+				t.Errorf("text = %q", out.Text)
+			}
+
+			if len(p.calls) != tt.calls {
+				t.Fatalf("calls = %d, want %d", len(p.calls), tt.calls)
+			}
+			first := p.calls[0]
+			if first.Format != "json" || first.Schema == nil || !strings.Contains(first.SysPrompt, "# Response Schema") {
+				t.Errorf("first call did not ask for the schema: %+v", first)
+			}
+			if first.UserMsg != "notes" {
+				t.Errorf("first message = %q, want the notes alone", first.UserMsg)
+			}
+			for _, c := range p.calls[1:] {
+				if !strings.Contains(c.UserMsg, "# Your previous reply was rejected") || !strings.HasPrefix(c.UserMsg, "notes") {
+					t.Errorf("retry message = %q, want the notes and the rejection", c.UserMsg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"vexora-studio/internal/prompts"
+)
+
+// Thread switches the twitter platform from a single tweet to a thread.
+// The output is then a JSON array of tweets, first the hook, last the CTA.
+type Thread struct {
+	Length    int    `json:"length,omitempty"` // 0 lets the model decide
+	Numbering bool   `json:"numbering"`        // prefix tweets with "1/7"
+	CTA       string `json:"cta,omitempty"`    // what the closing tweet asks for
+}
+
+// FormatThread marks thread outputs in Result.Metadata["format"]
+const FormatThread = "thread"
+
+// MaxThreadLength bounds Thread.Length
+const MaxThreadLength = 25
+
+// leadingNumber matches numbering the model added anyway, e.g. "1/", "2/7" or "3."
+var leadingNumber = regexp.MustCompile(`^\s*\d+\s*(?:/\s*\d*|\.)\s*`)
+
+// IsThread reports whether a stored feed's metadata marks it as a thread
+func IsThread(metadata json.RawMessage) bool {
+	var meta struct {
+		Format string `json:"format"`
+	}
+	return len(metadata) > 0 && json.Unmarshal(metadata, &meta) == nil && meta.Format == FormatThread
+}
+
+// ThreadTweets decodes a thread output
+func ThreadTweets(output string) ([]string, error) {
+	var tweets []string
+	err := json.Unmarshal([]byte(output), &tweets)
+	return tweets, err
+}
+
+func genTwitter(ctx context.Context, in Input, opts Options) (Result, error) {
+	if in.Thread != nil {
+		return genThread(ctx, in, opts, in.Notes)
+	}
+	return structuredGenerator[Tweet](prompts.Twitter)(ctx, in, opts)
+}
+
+// streamTwitter streams single tweets; a thread is a JSON object, so it is
+// generated whole and emitted at once
+func streamTwitter(ctx context.Context, in Input, opts Options, onToken func(string) error) (Result, error) {
+	if in.Thread == nil {
+		return structuredStreamer[Tweet](prompts.Twitter)(ctx, in, opts, onToken)
+	}
+	res, err := genThread(ctx, in, opts, in.Notes)
+	if err != nil {
+		return res, err
+	}
+	return res, onToken(res.Text)
+}
+
+func repairTwitter(ctx context.Context, in Input, opts Options, prev Result, violations []Violation) (Result, error) {
+	if in.Thread == nil {
+		return structuredRepairer[Tweet](prompts.Twitter)(ctx, in, opts, prev, violations)
+	}
+	tweets, err := ThreadTweets(prev.Text)
+	if err != nil {
+		return Result{}, err
+	}
+	for i, t := range tweets {
+		tweets[i] = leadingNumber.ReplaceAllString(t, "")
+	}
+	draft, _ := json.Marshal(ThreadReply{Tweets: tweets})
+	return genThread(ctx, in, opts, repairMessage(in.Notes, string(draft), violations))
+}
+
+// genThread asks for {"tweets": [...]} and numbers the result
+func genThread(ctx context.Context, in Input, opts Options, userMsg string) (Result, error) {
+	sys, version, err := in.render(prompts.TwitterThread)
+	if err != nil {
+		return Result{}, err
+	}
+	var out ThreadReply
+	res, err := GenerateStructured(ctx, sys[0], userMsg, SchemaFor(out), opts, &out)
+	if err != nil {
+		return Result{}, err
+	}
+	var tweets []string
+	for _, t := range out.Tweets {
+		if t = strings.TrimSpace(leadingNumber.ReplaceAllString(t, "")); t != "" {
+			tweets = append(tweets, t)
+		}
+	}
+	if len(tweets) == 0 {
+		return Result{}, fmt.Errorf("thread parse failed: no tweets")
+	}
+	if in.Thread.Numbering {
+		for i := range tweets {
+			tweets[i] = fmt.Sprintf("%d/%d %s", i+1, len(tweets), tweets[i])
+		}
+	}
+
+	text, _ := json.Marshal(tweets)
+	res.Text = string(text)
+	res.Metadata = map[string]any{"format": FormatThread, "thread": in.Thread}
+	res.PromptVersion = version
+	return res, nil
+}
+
+func validateThread(res Result, t *Thread) []Violation {
+	tweets, err := ThreadTweets(res.Text)
+	if err != nil {
+		return []Violation{{"format", "The thread could not be parsed."}}
+	}
+
+	var v []Violation
+	if t.Length > 0 && len(tweets) != t.Length {
+		v = append(v, Violation{"thread_length", fmt.Sprintf("The thread has %d tweets; it must have exactly %d.", len(tweets), t.Length)})
+	} else if len(tweets) < 2 {
+		v = append(v, Violation{"thread_length", "A thread needs at least 2 tweets."})
+	}
+	for i, tweet := range tweets {
+		if n := TwitterLength(tweet); n > TwitterMaxWeighted {
+			v = append(v, Violation{"max_length", fmt.Sprintf("Tweet %d is %d characters long as X counts them, numbering included; it must be at most %d.", i+1, n, TwitterMaxWeighted)})
+		}
+	}
+	if n := len(Hashtags(strings.Join(tweets, "\n"))); n != TwitterHashtags {
+		v = append(v, Violation{"hashtag_count", fmt.Sprintf("The thread has %d hashtags; it must have exactly %d.", n, TwitterHashtags)})
+	}
+	return v
+}
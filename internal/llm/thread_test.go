@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestThread(t *testing.T) {
+	long := strings.Repeat("a", TwitterMaxWeighted)
+	tests := []struct {
+		name    string
+		thread  Thread
+		replies []string
+		tweets  []string
+		rules   []string // violations left in the output
+		error   string
+	}{
+		{"numbered", Thread{Numbering: true},
+			[]string{`{"tweets": ["Hook #golang", "Middle", "Try it #sqlite"]}`},
+			[]string{"1/3 Hook #golang", "2/3 Middle", "3/3 Try it #sqlite"}, nil, ""},
+		{"unnumbered", Thread{},
+			[]string{`{"tweets": ["Hook #golang", "Try it #sqlite"]}`},
+			[]string{"Hook #golang", "Try it #sqlite"}, nil, ""},
+		{"model numbering is replaced", Thread{Numbering: true},
+			[]string{`{"tweets": ["1/ Hook #golang", "2/7 Middle", "3. Try it #sqlite"]}`},
+			[]string{"1/3 Hook #golang", "2/3 Middle", "3/3 Try it #sqlite"}, nil, ""},
+		{"model numbering is stripped", Thread{},
+			[]string{`{"tweets": ["1/2 Hook #golang", " 2 / Try it #sqlite"]}`},
+			[]string{"Hook #golang", "Try it #sqlite"}, nil, ""},
+		{"empty tweets are dropped", Thread{Numbering: true},
+			[]string{`{"tweets": ["Hook #golang", "  ", "3/", "Try it #sqlite"]}`},
+			[]string{"1/2 Hook #golang", "2/2 Try it #sqlite"}, nil, ""},
+		{"numbering counts toward the limit", Thread{Numbering: true},
+			[]string{`{"tweets": ["Hook #golang #sqlite", "` + long[:TwitterMaxWeighted-2] + `"]}`},
+			[]string{"1/2 Hook #golang #sqlite", "2/2 " + long[:TwitterMaxWeighted-2]}, []string{"max_length"}, ""},
+		{"wrong length", Thread{Length: 3},
+			[]string{`{"tweets": ["Hook #golang", "Try it #sqlite"]}`},
+			[]string{"Hook #golang", "Try it #sqlite"}, []string{"thread_length"}, ""},
+		{"single tweet", Thread{},
+			[]string{`{"tweets": ["Hook #golang #sqlite"]}`},
+			[]string{"Hook #golang #sqlite"}, []string{"thread_length"}, ""},
+		{"hashtags count across the thread", Thread{},
+			[]string{`{"tweets": ["Hook #golang", "Middle #go", "Try it #sqlite"]}`},
+			[]string{"Hook #golang", "Middle #go", "Try it #sqlite"}, []string{"hashtag_count"}, ""},
+		{"no tweets", Thread{}, []string{`{"tweets": [" ", "2/"]}`}, nil, nil, "no tweets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_REPAIR_ATTEMPTS", "0")
+			useProvider(t, &scriptedProvider{replies: tt.replies})
+
+			thread := tt.thread
+			res, err := GenerateContent(TypeTwitter, Input{Notes: "release notes", Thread: &thread}, Options{})
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("error = %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateContent: %v", err)
+			}
+			tweets, err := ThreadTweets(res.Text)
+			if err != nil {
+				t.Fatalf("ThreadTweets(%q): %v", res.Text, err)
+			}
+			if !slices.Equal(tweets, tt.tweets) {
+				t.Errorf("tweets = %q, want %q", tweets, tt.tweets)
+			}
+			if res.Metadata["format"] != FormatThread {
+				t.Errorf("format = %v, want %s", res.Metadata["format"], FormatThread)
+			}
+			var rules []string
+			for _, v := range res.Validation.Violations {
+				rules = append(rules, v.Rule)
+			}
+			if !slices.Equal(rules, tt.rules) {
+				t.Errorf("violations = %v, want %v", rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestThreadRepair(t *testing.T) {
+	t.Setenv("LLM_REPAIR_ATTEMPTS", "1")
+	p := &scriptedProvider{replies: []string{
+		`{"tweets": ["Hook #golang", "Try it"]}`,
+		`{"tweets": ["Hook #golang", "Try it #sqlite"]}`,
+	}}
+	useProvider(t, p)
+
+	res, err := GenerateContent(TypeTwitter, Input{Notes: "release notes", Thread: &Thread{Numbering: true}}, Options{})
+	if err != nil {
+		t.Fatalf("GenerateContent: %v", err)
+	}
+	if res.Text != `["1/2 Hook #golang","2/2 Try it #sqlite"]` {
+		t.Errorf("Text = %s", res.Text)
+	}
+	if !res.Validation.Valid || len(res.Validation.Attempts) != 2 {
+		t.Errorf("validation = %+v, want valid after one repair", res.Validation)
+	}
+	if len(p.calls) != 2 {
+		t.Fatalf("%d calls, want 2", len(p.calls))
+	}
+	// the draft goes back without the numbering, which is added again
+	if msg := p.calls[1].UserMsg; !strings.Contains(msg, `Previous draft:
+{"tweets":["Hook #golang","Try it"]}`) {
+		t.Errorf("repair message = %q", msg)
+	}
+}
+
+func TestIsThread(t *testing.T) {
+	tests := []struct {
+		metadata string
+		want     bool
+	}{
+		{`{"format": "thread", "thread": {"numbering": true}}`, true},
+		{`{"text": "Shipped it."}`, false},
+		{`{"format": "markdown"}`, false},
+		{``, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if got := IsThread(json.RawMessage(tt.metadata)); got != tt.want {
+			t.Errorf("IsThread(%s) = %v, want %v", tt.metadata, got, tt.want)
+		}
+	}
+}
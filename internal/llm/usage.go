@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is the token count of one call, or of a whole generation once
+// GenerateContent has summed its calls
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"` // estimated from the price table
+}
+
+func (u *Usage) add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.CostUSD += o.CostUSD
+}
+
+// Price is USD per million tokens
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// defaultPrices are the published list prices. Local providers are free.
+// LLM_PRICES adds or overrides entries.
+var defaultPrices = map[string]Price{
+	"gemini-2.5-pro":        {1.25, 10.00},
+	"gemini-2.5-flash":      {0.30, 2.50},
+	"gemini-2.5-flash-lite": {0.10, 0.40},
+	"gemini-2.0-flash":      {0.10, 0.40},
+	"gemini-2.0-flash-lite": {0.075, 0.30},
+	ProviderOllama:          {0, 0},
+}
+
+// defaultPrice is charged for models without a price, so an unpriced model
+// can't slip past a cost budget: the most expensive list price above
+var defaultPrice = Price{1.25, 10.00}
+
+// priceFor looks the model up, then the provider, in LLM_PRICES
+// ("gpt-4o-mini=0.15/0.60,vllm=0/0", input/output per million tokens) and
+// then in defaultPrices. Unknown models are logged and charged
+// LLM_DEFAULT_PRICE ("1.25/10"), by default the most expensive list price.
+func priceFor(provider, model string) Price {
+	prices := map[string]Price{}
+	for k, v := range defaultPrices {
+		prices[k] = v
+	}
+	for _, entry := range strings.Split(os.Getenv("LLM_PRICES"), ",") {
+		name, rates, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		p, err := parsePrice(rates)
+		if err != nil {
+			log.Printf("⚠️ Ignoring LLM_PRICES entry %q", entry)
+			continue
+		}
+		prices[strings.ToLower(strings.TrimSpace(name))] = p
+	}
+
+	if p, ok := prices[strings.ToLower(model)]; ok {
+		return p
+	}
+	if p, ok := prices[strings.ToLower(provider)]; ok {
+		return p
+	}
+	price := defaultPrice
+	if v := os.Getenv("LLM_DEFAULT_PRICE"); v != "" {
+		p, err := parsePrice(v)
+		if err != nil {
+			log.Printf("⚠️ Ignoring LLM_DEFAULT_PRICE %q", v)
+		} else {
+			price = p
+		}
+	}
+	log.Printf("🚨 No price for %s/%s, charging the default $%g/$%g per million tokens (set LLM_PRICES)", provider, model, price.Input, price.Output)
+	return price
+}
+
+// parsePrice reads "input/output" in USD per million tokens
+func parsePrice(rates string) (Price, error) {
+	in, out, ok := strings.Cut(rates, "/")
+	if !ok {
+		return Price{}, fmt.Errorf("want input/output, got %q", rates)
+	}
+	pin, err := strconv.ParseFloat(strings.TrimSpace(in), 64)
+	if err != nil {
+		return Price{}, err
+	}
+	pout, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil {
+		return Price{}, err
+	}
+	return Price{pin, pout}, nil
+}
+
+func (p Price) cost(u Usage) float64 {
+	c := (float64(u.PromptTokens)*p.Input + float64(u.CompletionTokens)*p.Output) / 1e6
+	return math.Round(c*1e8) / 1e8
+}
+
+// meter sums the calls made for one platform generation and labels their
+// ledger rows. GenerateContent and StreamContent put one in the context.
+type meter struct {
+	mu       sync.Mutex
+	project  string
+	platform string
+	usage    Usage
+}
+
+type meterKey struct{}
+
+func withMeter(ctx context.Context, project, platform string) (context.Context, *meter) {
+	m := &meter{project: project, platform: platform}
+	return context.WithValue(ctx, meterKey{}, m), m
+}
+
+func (m *meter) total() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+// Call outcomes in the usage ledger
+const (
+	UsageOK        = "ok"
+	UsageFailed    = "failed"
+	UsageBlocked   = "blocked"   // refused by safety filters or stopped early
+	UsageCancelled = "cancelled" // the caller gave up
+)
+
+// recordUsage prices a call to provider, adds it to the generation's meter
+// and writes it to the llm_usage ledger. Failed calls are recorded too,
+// with their status and error.
+func recordUsage(ctx context.Context, provider string, opts Options, res *Result, latency time.Duration, err error) {
+	if res.Provider == "" {
+		res.Provider = provider
+	}
+	if res.Model == "" {
+		res.Model = opts.Model
+	}
+	if res.Usage.PromptTokens > 0 || res.Usage.CompletionTokens > 0 {
+		res.Usage.CostUSD = priceFor(res.Provider, res.Model).cost(res.Usage)
+	}
+
+	rec := UsageRecord{
+		Provider:         res.Provider,
+		Model:            res.Model,
+		PromptTokens:     res.Usage.PromptTokens,
+		CompletionTokens: res.Usage.CompletionTokens,
+		Latency:          latency,
+		CostUSD:          res.Usage.CostUSD,
+		Status:           UsageOK,
+	}
+	if err != nil {
+		rec.Status, rec.Error = UsageFailed, err.Error()
+		if ctx.Err() != nil {
+			rec.Status = UsageCancelled
+		} else if isContentError(err) {
+			rec.Status = UsageBlocked
+		}
+	}
+	if m, ok := ctx.Value(meterKey{}).(*meter); ok {
+		m.mu.Lock()
+		m.usage.add(res.Usage)
+		m.mu.Unlock()
+		rec.Project, rec.Platform = m.project, m.platform
+	}
+
+	if store == nil {
+		return
+	}
+	if err := store.RecordUsage(rec); err != nil {
+		log.Printf("❌ Failed to record LLM usage: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import "testing"
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		name            string
+		prices          string // LLM_PRICES
+		def             string // LLM_DEFAULT_PRICE
+		provider, model string
+		want            Price
+	}{
+		{"list price", "", "", ProviderGemini, "gemini-2.5-flash", Price{0.30, 2.50}},
+		{"local provider", "", "", ProviderOllama, "gemma3", Price{}},
+		{"override", "gemini-2.5-flash=1/2", "", ProviderGemini, "gemini-2.5-flash", Price{1, 2}},
+		{"provider entry", "vllm=0/0", "", "vllm", "llama3", Price{}},
+		{"unknown model", "", "", "vllm", "llama3", defaultPrice},
+		{"unknown model, configured default", "", "3/4", "vllm", "llama3", Price{3, 4}},
+		{"bad default", "", "cheap", "vllm", "llama3", defaultPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_PRICES", tt.prices)
+			t.Setenv("LLM_DEFAULT_PRICE", tt.def)
+			if got := priceFor(tt.provider, tt.model); got != tt.want {
+				t.Errorf("priceFor(%s, %s) = %v, want %v", tt.provider, tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordUsageFailure(t *testing.T) {
+	s := &fakeStore{}
+	useStore(t, s)
+	ctx, m := withMeter(t.Context(), "acme", TypeTwitter)
+
+	var res Result
+	recordUsage(ctx, ProviderOllama, Options{Model: "gemma3"}, &res, 0, &BlockedError{Provider: ProviderOllama, Reason: "SAFETY"})
+	res = Result{Provider: ProviderGemini, Model: "gemini-2.5-flash", Usage: Usage{PromptTokens: 1e6}}
+	recordUsage(ctx, ProviderGemini, Options{}, &res, 0, nil)
+
+	if len(s.usage) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(s.usage))
+	}
+	if got := s.usage[0]; got.Status != UsageBlocked || got.Error == "" || got.Model != "gemma3" || got.Project != "acme" {
+		t.Errorf("failed call recorded as %+v", got)
+	}
+	if got := s.usage[1]; got.Status != UsageOK || got.CostUSD != 0.30 {
+		t.Errorf("successful call recorded as %+v", got)
+	}
+	if got := m.total(); got.PromptTokens != 1e6 {
+		t.Errorf("meter total = %+v", got)
+	}
+}
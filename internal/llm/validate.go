@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is one platform rule an output breaks. Message is written for
+// the model: it is sent back verbatim when asking for a repair.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationReport says whether the returned output passed its platform's
+// checks and what happened along the way. Attempts holds the violations of
+// every draft, the first one included.
+type ValidationReport struct {
+	Valid      bool          `json:"valid"`
+	Violations []Violation   `json:"violations,omitempty"` // left in the returned output
+	Attempts   [][]Violation `json:"attempts"`
+	Error      string        `json:"error,omitempty"` // why repairing stopped early
+}
+
+// Platform limits
+const (
+	TwitterMaxWeighted   = 280
+	TwitterURLWeight     = 23
+	TwitterHashtags      = 2 // the twitter prompt asks for exactly two
+	LinkedInMaxChars     = 3000
+	InstagramMaxChars    = 2200
+	InstagramMaxHashtags = 30
+	NewsletterMaxSubject = 60
+	NewsletterMaxPreview = 100
+)
+
+var (
+	urlPattern     = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+`)
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]*[\p{L}_][\p{L}\p{N}_]*)`)
+)
+
+// Hashtags returns the hashtags in text, in order
+func Hashtags(text string) []string {
+	var tags []string
+	for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, "#"+m[1])
+	}
+	return tags
+}
+
+// TwitterLength counts text the way X does: every URL is 23, Latin and
+// general punctuation count 1 and everything else (CJK, emoji, ...) counts 2
+func TwitterLength(text string) int {
+	n := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		n += TwitterURLWeight
+		text = text[:loc[0]] + strings.Repeat("\x00", loc[1]-loc[0]) + text[loc[1]:]
+	}
+
+	joined := false // the rune after a zero width joiner is part of the same emoji
+	for _, r := range text {
+		switch {
+		case r == 0:
+		case r == 0x200D:
+			joined = true
+		case joined, r == 0xFE0E, r == 0xFE0F, r >= 0x1F3FB && r <= 0x1F3FF:
+			joined = false
+		case r <= 0x10FF, r >= 0x2000 && r <= 0x200C, r >= 0x2010 && r <= 0x201F, r >= 0x2032 && r <= 0x2037:
+			n++
+		default:
+			n += 2
+		}
+	}
+	return n
+}
+
+func validateTwitter(res Result) []Violation {
+	if t, ok := res.Metadata["thread"].(*Thread); ok {
+		return validateThread(res, t)
+	}
+	var v []Violation
+	if n := TwitterLength(res.Text); n > TwitterMaxWeighted {
+		v = append(v, Violation{"max_length", fmt.Sprintf("The tweet is %d characters long as X counts them (links count as %d); it must be at most %d.", n, TwitterURLWeight, TwitterMaxWeighted)})
+	}
+	if n := len(Hashtags(res.Text)); n != TwitterHashtags {
+		v = append(v, Violation{"hashtag_count", fmt.Sprintf("The tweet has %d hashtags; it must have exactly %d.", n, TwitterHashtags)})
+	}
+	return v
+}
+
+func validateLinkedIn(res Result) []Violation {
+	if n := utf8.RuneCountInString(res.Text); n > LinkedInMaxChars {
+		return []Violation{{"max_length", fmt.Sprintf("The post is %d characters long; it must be at most %d.", n, LinkedInMaxChars)}}
+	}
+	return nil
+}
+
+func validateInstagram(res Result) []Violation {
+	var v []Violation
+	if n := utf8.RuneCountInString(res.Text); n > InstagramMaxChars {
+		v = append(v, Violation{"max_length", fmt.Sprintf("The caption is %d characters long; it must be at most %d.", n, InstagramMaxChars)})
+	}
+	if n := len(Hashtags(res.Text)); n > InstagramMaxHashtags {
+		v = append(v, Violation{"max_hashtags", fmt.Sprintf("The caption has %d hashtags; Instagram allows at most %d.", n, InstagramMaxHashtags)})
+	}
+	return v
+}
+
+func validateNewsletter(res Result) []Violation {
+	var nl struct {
+		Subject string `json:"subject_line"`
+		Preview string `json:"preview_text"`
+	}
+	if err := json.Unmarshal([]byte(res.Text), &nl); err != nil {
+		return []Violation{{"format", "The newsletter could not be parsed."}}
+	}
+
+	var v []Violation
+	if strings.TrimSpace(nl.Subject) == "" {
+		v = append(v, Violation{"subject_missing", "The subject_line is empty."})
+	} else if n := utf8.RuneCountInString(nl.Subject); n > NewsletterMaxSubject {
+		v = append(v, Violation{"subject_length", fmt.Sprintf("The subject_line is %d characters long; it must be at most %d.", n, NewsletterMaxSubject)})
+	}
+	if n := utf8.RuneCountInString(nl.Preview); n > NewsletterMaxPreview {
+		v = append(v, Violation{"preview_length", fmt.Sprintf("The preview_text is %d characters long; it must be at most %d.", n, NewsletterMaxPreview)})
+	}
+	return v
+}
+
+// repairAttempts is how many times a failing output is sent back to the
+// model (LLM_REPAIR_ATTEMPTS, default 2, 0 only reports)
+func repairAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("LLM_REPAIR_ATTEMPTS")); err == nil && n >= 0 {
+		return n
+	}
+	return 2
+}
+
+// repairMessage asks the model to fix draft. It replaces the notes as the
+// user message; the system prompt stays the same.
+func repairMessage(notes, draft string, violations []Violation) string {
+	var sb strings.Builder
+	sb.WriteString("Here are my notes:\n" + notes + "\n\n")
+	sb.WriteString("Your previous draft breaks these rules:\n")
+	for _, v := range violations {
+		sb.WriteString("- " + v.Message + "\n")
+	}
+	sb.WriteString("\nRewrite it so it follows every rule. Keep the content and the voice.\n\n")
+	sb.WriteString("Previous draft:\n" + draft)
+	return sb.String()
+}
+
+// validated checks res against the platform and, while it fails, asks for
+// a repair up to repairAttempts times. The last draft is returned either
+// way, with the report attached. Platforms without Validate pass through.
+func validated(ctx context.Context, p Platform, in Input, opts Options, res Result) Result {
+	if p.Validate == nil {
+		return res
+	}
+
+	check := func(r Result) []Violation {
+		if v := p.Validate(r); v != nil {
+			return v
+		}
+		return []Violation{}
+	}
+
+	report := &ValidationReport{}
+	violations := check(res)
+	report.Attempts = append(report.Attempts, violations)
+	for i := 0; len(violations) > 0 && i < repairAttempts() && p.Repair != nil; i++ {
+		log.Printf("🔧 %s output broke %d rules, asking for a repair (%d)", p.Name, len(violations), i+1)
+		fixed, err := p.Repair(ctx, in, opts, res, violations)
+		if err != nil {
+			log.Printf("❌ %s repair failed: %v", p.Name, err)
+			report.Error = err.Error()
+			break
+		}
+		fixed.PromptVersion = res.PromptVersion
+		res = fixed
+		violations = check(res)
+		report.Attempts = append(report.Attempts, violations)
+	}
+
+	report.Valid = len(violations) == 0
+	if !report.Valid {
+		report.Violations = violations
+	}
+	res.Validation = report
+	if res.Metadata == nil {
+		res.Metadata = map[string]any{}
+	}
+	res.Metadata["validation"] = report
+	return res
+}
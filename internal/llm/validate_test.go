@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTwitterLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello world", 11},
+		{"latin accents", "café déjà vu", 12},
+		{"url", "read https://example.com/a/very/long/path/to/the/post now", 5 + TwitterURLWeight + 4},
+		{"www url", "www.example.com", TwitterURLWeight},
+		{"two urls", "http://a.io https://b.io/x", 2*TwitterURLWeight + 1},
+		{"cjk", "日本語", 6},
+		{"mixed cjk", "Go 言語", 7},
+		{"general punctuation", "a—b “c”", 7},
+		{"ellipsis counts 2", "…", 2},
+		{"emoji", "🚀", 2},
+		{"skin tone", "👍🏽", 2},
+		{"variation selector", "❤️", 2},
+		{"zwj sequence", "👨‍👩‍👧", 2},
+		{"flag", "🇫🇷", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TwitterLength(tt.text); got != tt.want {
+				t.Errorf("TwitterLength(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no tags", nil},
+		{"#golang and #sqlite", []string{"#golang", "#sqlite"}},
+		{"#日本語 #café", []string{"#日本語", "#café"}},
+		{"issue #42 is fixed #v2", []string{"#v2"}},
+		{"a#b https://x.io/#anchor &#39; ##double", nil},
+		{"(#wrapped), #end.", []string{"#wrapped", "#end"}},
+	}
+	for _, tt := range tests {
+		if got := Hashtags(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Hashtags(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tags := " #golang #sqlite"
+	newsletter := func(subject, preview string) string {
+		return `{"subject_line": "` + subject + `", "preview_text": "` + preview + `", "body": "Hi"}`
+	}
+	tests := []struct {
+		name     string
+		validate func(Result) []Violation
+		text     string
+		rules    []string
+	}{
+		{"tweet", validateTwitter, "Shipped it." + tags, nil},
+		{"tweet at the limit", validateTwitter, strings.Repeat("a", TwitterMaxWeighted-len(tags)) + tags, nil},
+		{"tweet too long", validateTwitter, strings.Repeat("a", TwitterMaxWeighted-len(tags)+1) + tags, []string{"max_length"}},
+		{"tweet too long in cjk", validateTwitter, strings.Repeat("語", 140) + tags, []string{"max_length"}},
+		{"tweet with a long url", validateTwitter, "https://example.com/" + strings.Repeat("a", 300) + tags, nil},
+		{"tweet with one hashtag", validateTwitter, "Shipped it. #golang", []string{"hashtag_count"}},
+		{"tweet with three hashtags", validateTwitter, "Shipped it. #a #b #c", []string{"hashtag_count"}},
+		{"tweet breaking both", validateTwitter, strings.Repeat("a", 300), []string{"max_length", "hashtag_count"}},
+
+		{"linkedin", validateLinkedIn, strings.Repeat("a", LinkedInMaxChars), nil},
+		{"linkedin too long", validateLinkedIn, strings.Repeat("é", LinkedInMaxChars+1), []string{"max_length"}},
+
+		{"instagram", validateInstagram, "New release" + strings.Repeat(" #t", InstagramMaxHashtags), nil},
+		{"instagram too long", validateInstagram, strings.Repeat("a", InstagramMaxChars+1), []string{"max_length"}},
+		{"instagram too many hashtags", validateInstagram, "New release" + strings.Repeat(" #t", InstagramMaxHashtags+1), []string{"max_hashtags"}},
+
+		{"newsletter", validateNewsletter, newsletter("v2 is out", "What changed"), nil},
+		{"newsletter subject at the limit", validateNewsletter, newsletter(strings.Repeat("s", NewsletterMaxSubject), ""), nil},
+		{"newsletter subject too long", validateNewsletter, newsletter(strings.Repeat("s", NewsletterMaxSubject+1), ""), []string{"subject_length"}},
+		{"newsletter subject missing", validateNewsletter, newsletter("  ", ""), []string{"subject_missing"}},
+		{"newsletter preview too long", validateNewsletter, newsletter("v2", strings.Repeat("p", NewsletterMaxPreview+1)), []string{"preview_length"}},
+		{"newsletter that isn't JSON", validateNewsletter, "Subject: v2", []string{"format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []string
+			for _, v := range tt.validate(Result{Text: tt.text}) {
+				rules = append(rules, v.Rule)
+				if v.Message == "" {
+					t.Errorf("%s has no message", v.Rule)
+				}
+			}
+			if !slices.Equal(rules, tt.rules) {
+				t.Errorf("rules = %v, want %v", rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestValidated(t *testing.T) {
+	valid, invalid := Result{Text: "ok"}, Result{Text: "too long"}
+	validate := func(r Result) []Violation {
+		if r.Text == "ok" {
+			return nil
+		}
+		return []Violation{{"max_length", "The post is too long."}}
+	}
+	tests := []struct {
+		name     string
+		attempts string
+		first    Result
+		repairs  []Result // an empty Result fails
+		valid    bool
+		drafts   int // len(report.Attempts)
+		error    bool
+	}{
+		{"valid", "", valid, nil, true, 1, false},
+		{"repaired", "", invalid, []Result{valid}, true, 2, false},
+		{"repaired on the second try", "", invalid, []Result{invalid, valid}, true, 3, false},
+		{"repairs exhausted", "", invalid, []Result{invalid, invalid, valid}, false, 3, false},
+		{"more attempts configured", "3", invalid, []Result{invalid, invalid, valid}, true, 4, false},
+		{"report only", "0", invalid, []Result{valid}, false, 1, false},
+		{"repair fails", "", invalid, []Result{{}}, false, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_REPAIR_ATTEMPTS", tt.attempts)
+			repairs := 0
+			p := Platform{Name: "test", Validate: validate,
+				Repair: func(ctx context.Context, in Input, opts Options, prev Result, violations []Violation) (Result, error) {
+					if len(violations) == 0 || prev.Text == "ok" {
+						t.Errorf("repair of a valid draft")
+					}
+					r := tt.repairs[repairs]
+					repairs++
+					if r.Text == "" {
+						return Result{}, errors.New("provider down")
+					}
+					return r, nil
+				}}
+
+			first := tt.first
+			first.PromptVersion = "v7"
+			res := validated(context.Background(), p, Input{}, Options{}, first)
+			report := res.Validation
+			if report == nil || res.Metadata["validation"] != report {
+				t.Fatal("no validation report attached")
+			}
+			if report.Valid != tt.valid || (len(report.Violations) == 0) != tt.valid {
+				t.Errorf("valid = %v with violations %v, want %v", report.Valid, report.Violations, tt.valid)
+			}
+			if len(report.Attempts) != tt.drafts {
+				t.Errorf("attempts = %d, want %d", len(report.Attempts), tt.drafts)
+			}
+			if (report.Error != "") != tt.error {
+				t.Errorf("error = %q", report.Error)
+			}
+			if res.PromptVersion != "v7" {
+				t.Errorf("prompt version = %q, want the first draft's", res.PromptVersion)
+			}
+		})
+	}
+}
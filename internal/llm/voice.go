@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"vexora-studio/internal/prompts"
+)
+
+var ErrUnknownVoice = errors.New("unknown voice profile")
+
+// withVoice loads the voice profile for in: the one named by the request,
+// else the one attached to the project, else none
+func (in Input) withVoice() (Input, error) {
+	if in.profile != nil || store == nil {
+		return in, nil
+	}
+	var err error
+	if in.VoiceProfile != "" {
+		in.profile, err = store.Voice(in.VoiceProfile)
+		if err == nil && in.profile == nil {
+			return in, fmt.Errorf("%w: %s", ErrUnknownVoice, in.VoiceProfile)
+		}
+		return in, err
+	}
+	if in.Project != "" {
+		in.profile, err = store.ProjectVoice(in.Project)
+	}
+	return in, err
+}
+
+// voiceID is appended to the prompt version, e.g. "voice:acme@v3"
+func (in Input) voiceID() string {
+	if in.profile == nil {
+		return ""
+	}
+	return fmt.Sprintf("voice:%s@v%d", in.profile.Name, in.profile.Version)
+}
+
+// voiceRules is the part of the profile the templates have no variables
+// for. It goes after the rendered template so it wins over its defaults.
+func (in Input) voiceRules(template string) string {
+	v := in.profile
+	if v == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n\n# Brand Voice (%s)\nThese rules override the guidelines above.\n", v.Name)
+	if v.Persona != "" {
+		fmt.Fprintf(&sb, "- Write as %s.\n", v.Persona)
+	}
+	switch v.Emoji {
+	case "none":
+		sb.WriteString("- Do not use any emoji.\n")
+	case "light":
+		sb.WriteString("- Use emoji sparingly: two at most.\n")
+	case "heavy":
+		sb.WriteString("- Use emoji generously.\n")
+	}
+	if len(v.BannedWords) > 0 {
+		fmt.Fprintf(&sb, "- Never use these words or phrases: %s.\n", strings.Join(v.BannedWords, ", "))
+	}
+
+	// the newsletter meta prompt returns JSON, sample posts would only confuse it
+	platform := template
+	switch template {
+	case prompts.NewsletterBody:
+		platform = TypeNewsletter
+	case prompts.TwitterThread:
+		platform = TypeTwitter
+	}
+	var examples []string
+	for _, ex := range v.Examples {
+		if template != prompts.NewsletterMeta && (ex.Platform == "" || ex.Platform == platform) {
+			examples = append(examples, strings.TrimSpace(ex.Text))
+		}
+	}
+	if len(examples) > 0 {
+		sb.WriteString("\n# Example Posts\nMatch the style of these past posts. Do not copy them.\n")
+		for _, ex := range examples {
+			sb.WriteString("---\n" + ex + "\n")
+		}
+		sb.WriteString("---\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
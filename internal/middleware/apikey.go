@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"vexora-studio/internal/database"
+)
+
+// API keys are sent as "Authorization: Bearer <key>" or in X-API-Key.
+// Browsers can send one as the Basic auth password (the user name is
+// ignored), see Browser. VEXORA_AUTH=off turns authentication off for
+// local development; every request is then let through without a caller.
+
+type callerKey struct{}
+
+// Caller returns the API key a request was made with, nil if none
+func Caller(ctx context.Context) *database.APIKey {
+	k, _ := ctx.Value(callerKey{}).(*database.APIKey)
+	return k
+}
+
+// AuthEnabled reports whether API keys are enforced
+func AuthEnabled() bool {
+	return !strings.EqualFold(os.Getenv("VEXORA_AUTH"), "off")
+}
+
+// RequireScope lets the request through only with an active key granting scope
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !AuthEnabled() {
+			next(w, r)
+			return
+		}
+		key, ok := authenticate(w, r)
+		if !ok {
+			return
+		}
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vexora"`)
+			http.Error(w, "API key required", 401)
+			return
+		}
+		if !key.HasScope(scope) {
+			http.Error(w, "API key lacks the "+scope+" scope", 403)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, key)))
+	}
+}
+
+// OptionalKey attaches the caller if the request carries a key, for routes
+// that also accept other credentials (e.g. signed approval links)
+func OptionalKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !AuthEnabled() {
+			next(w, r)
+			return
+		}
+		key, ok := authenticate(w, r)
+		if !ok {
+			return
+		}
+		if key != nil {
+			r = r.WithContext(context.WithValue(r.Context(), callerKey{}, key))
+		}
+		next(w, r)
+	}
+}
+
+// Browser adds a Basic challenge to 401 responses so browsers prompt for a
+// key, for pages such as the dashboard
+func Browser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(basicChallenge{w}, r)
+	}
+}
+
+type basicChallenge struct{ http.ResponseWriter }
+
+func (w basicChallenge) WriteHeader(code int) {
+	if code == http.StatusUnauthorized {
+		w.Header().Add("WWW-Authenticate", `Basic realm="vexora"`)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// authenticate looks up the request's key: nil without one, 401 for a bad one
+func authenticate(w http.ResponseWriter, r *http.Request) (*database.APIKey, bool) {
+	secret := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); secret == "" && auth != "" {
+		scheme, token, _ := strings.Cut(auth, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			secret = strings.TrimSpace(token)
+		} else if _, password, ok := r.BasicAuth(); ok {
+			secret = password
+		}
+	}
+	if secret == "" {
+		return nil, true
+	}
+
+	key, err := database.AuthenticateAPIKey(secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("⛔ Security Alert: invalid API key from %s", r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="vexora", error="invalid_token"`)
+		http.Error(w, "Invalid API key", 401)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("❌ DB Error: %v", err)
+		http.Error(w, "Database Error", 500)
+		return nil, false
+	}
+	return key, true
+}
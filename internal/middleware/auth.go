@@ -1,43 +1,236 @@
 package middleware
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-func ValidateSignature(secret string) func(http.Handler) http.Handler {
+var (
+	ErrBadSignature    = errors.New("invalid signature")
+	ErrStaleTimestamp  = errors.New("timestamp outside the tolerance window")
+	ErrReplayedRequest = errors.New("request already received")
+)
+
+// Signature describes where a sender puts the HMAC-SHA256 of the request,
+// hex encoded with an optional "sha256=" prefix
+type Signature struct {
+	Header    string // e.g. X-Hub-Signature-256
+	Timestamp string // header with the unix time, signed as "<time>.<body>"; empty signs the body alone
+	Token     bool   // the header carries the shared secret itself instead (GitLab)
+}
+
+// VexoraSignature is the scheme of our own senders: X-Vexora-Signature
+// over "<X-Vexora-Timestamp>.<body>"
+var VexoraSignature = Signature{Header: "X-Vexora-Signature", Timestamp: "X-Vexora-Timestamp"}
+
+// Sign returns the X-Vexora-Signature value for body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	return "sha256=" + hmacHex(secret, signedPayload(strconv.FormatInt(t.Unix(), 10), body))
+}
+
+// SignatureConfig says how a signed request is checked. Zero values take
+// the defaults.
+type SignatureConfig struct {
+	Signature
+	Secrets   []string      // every active secret; keep the old one listed while rotating
+	Tolerance time.Duration // accepted clock skew of the timestamp, default SIGNATURE_TOLERANCE or 5m
+	MaxBody   int64         // largest body accepted, default 1 MiB
+	Nonces    *NonceCache   // signatures already seen, default a process-wide cache
+}
+
+// ParseSecrets splits a comma-separated secret list, e.g. from the environment
+func ParseSecrets(raw string) []string {
+	var secrets []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// ValidateSignature rejects requests not signed with one of cfg's secrets,
+// signed too long ago, replayed, or with bodies over the size limit
+func ValidateSignature(cfg SignatureConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Read the body
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
-				http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+			body, ok := CheckSignature(w, r, cfg)
+			if !ok {
 				return
 			}
-
 			// Restore the body so the next handler can read it
-			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			r.Body = io.NopCloser(strings.NewReader(string(body)))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-			gotSig := r.Header.Get("X-Vexora-Signature")
-			if !verifySignature(secret, bodyBytes, gotSig) {
-				log.Println("⛔ Security Alert: Invalid Signature")
-				http.Error(w, "Unauthorized", 401)
-				return
+// CheckSignature reads the body and verifies it like ValidateSignature,
+// writing the error response if it fails
+func CheckSignature(w http.ResponseWriter, r *http.Request, cfg SignatureConfig) ([]byte, bool) {
+	cfg = cfg.withDefaults()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.MaxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	now := time.Now()
+	err = cfg.Verify(cfg.Secrets, body, r.Header, now, cfg.Tolerance)
+	// A signature is unique to its timestamp and body, so it serves as the
+	// nonce, normalized so a re-encoded copy is the same one. It only needs
+	// remembering for as long as the timestamp is accepted. Untimestamped
+	// signatures repeat on every redelivery; callers dedupe those on
+	// ReplayKey themselves.
+	if err == nil && cfg.Timestamp != "" && !cfg.Nonces.Add(cfg.ReplayKey(r.Header, body), now, 2*cfg.Tolerance) {
+		err = ErrReplayedRequest
+	}
+	if err != nil {
+		log.Printf("⛔ Security Alert: %s from %s: %v", cfg.Header, r.RemoteAddr, err)
+		if errors.Is(err, ErrReplayedRequest) {
+			http.Error(w, "Duplicate request", http.StatusConflict)
+			return nil, false
+		}
+		http.Error(w, "Unauthorized", 401)
+		return nil, false
+	}
+	return body, true
+}
+
+func (c SignatureConfig) withDefaults() SignatureConfig {
+	if c.Tolerance <= 0 {
+		c.Tolerance = 5 * time.Minute
+		if d, err := time.ParseDuration(os.Getenv("SIGNATURE_TOLERANCE")); err == nil && d > 0 {
+			c.Tolerance = d
+		}
+	}
+	if c.MaxBody <= 0 {
+		c.MaxBody = 1 << 20
+	}
+	if c.Nonces == nil {
+		c.Nonces = defaultNonces
+	}
+	return c
+}
+
+// Verify checks the request against every secret with a constant-time
+// compare, and its timestamp against the tolerance window
+func (s Signature) Verify(secrets []string, body []byte, header http.Header, now time.Time, tolerance time.Duration) error {
+	got := header.Get(s.Header)
+	if len(secrets) == 0 || got == "" {
+		return ErrBadSignature
+	}
+	if s.Token {
+		for _, secret := range secrets {
+			if hmac.Equal([]byte(got), []byte(secret)) {
+				return nil
 			}
+		}
+		return ErrBadSignature
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	payload := body
+	if s.Timestamp != "" {
+		ts := header.Get(s.Timestamp)
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: missing or malformed %s", ErrBadSignature, s.Timestamp)
+		}
+		if skew := now.Sub(time.Unix(sec, 0)); skew > tolerance || skew < -tolerance {
+			return ErrStaleTimestamp
+		}
+		payload = signedPayload(ts, body)
+	}
+
+	got = normalizeMAC(got)
+	for _, secret := range secrets {
+		if hmac.Equal([]byte(got), []byte(hmacHex(secret, payload))) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// ReplayKey identifies a verified request across resends: the normalized
+// MAC for timestamped schemes, else the SHA-256 of the body, since the
+// signature and any delivery ID of those can be resent with the same body
+func (s Signature) ReplayKey(header http.Header, body []byte) string {
+	if s.Timestamp != "" {
+		return normalizeMAC(header.Get(s.Header))
 	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
 }
 
-func verifySignature(secret string, body []byte, gotSig string) bool {
+// normalizeMAC strips the optional prefix and lowercases the hex
+func normalizeMAC(sig string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(sig), "sha256="))
+}
+
+func signedPayload(ts string, body []byte) []byte {
+	return append([]byte(ts+"."), body...)
+}
+
+func hmacHex(secret string, payload []byte) string {
 	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	expectedSig := hex.EncodeToString(mac.Sum(nil))
-	return hmac.Equal([]byte(gotSig), []byte(expectedSig))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NonceCache remembers keys for a while to spot replayed requests. It is
+// in memory, so each process keeps its own.
+type NonceCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time // key -> forget after
+	nextGC  time.Time
+	maxSize int
+}
+
+var defaultNonces = NewNonceCache(100_000)
+
+// NewNonceCache holds at most maxSize keys; when full, new keys are refused
+// rather than forgetting ones that could then be replayed
+func NewNonceCache(maxSize int) *NonceCache {
+	return &NonceCache{seen: map[string]time.Time{}, maxSize: maxSize}
+}
+
+// Add records key for ttl. It reports false if key is already known, or
+// the cache is full.
+func (c *NonceCache) Add(key string, now time.Time, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.After(c.nextGC) || len(c.seen) >= c.maxSize {
+		for k, until := range c.seen {
+			if now.After(until) {
+				delete(c.seen, k)
+			}
+		}
+		c.nextGC = now.Add(time.Minute)
+	}
+	if until, ok := c.seen[key]; ok && !now.After(until) {
+		return false
+	}
+	if len(c.seen) >= c.maxSize {
+		log.Printf("⚠️ Nonce cache full (%d entries), refusing signed requests", c.maxSize)
+		return false
+	}
+	c.seen[key] = now.Add(ttl)
+	return true
 }
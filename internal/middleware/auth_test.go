@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"notes":"shipped"}`)
+	sig := Sign("s3cret", now, body)
+	bare := strings.TrimPrefix(sig, "sha256=")
+	plain := Signature{Header: "X-Hub-Signature-256"}
+	plainSig := hmacHex("s3cret", body)
+
+	tests := []struct {
+		name    string
+		scheme  Signature
+		secrets []string
+		sig, ts string
+		want    error
+	}{
+		{"signed", VexoraSignature, []string{"s3cret"}, sig, "1700000000", nil},
+		{"without prefix", VexoraSignature, []string{"s3cret"}, bare, "1700000000", nil},
+		{"uppercase hex", VexoraSignature, []string{"s3cret"}, "sha256=" + strings.ToUpper(bare), "1700000000", nil},
+		{"rotated secret", VexoraSignature, []string{"new", "s3cret"}, sig, "1700000000", nil},
+		{"wrong secret", VexoraSignature, []string{"other"}, sig, "1700000000", ErrBadSignature},
+		{"no secrets", VexoraSignature, nil, sig, "1700000000", ErrBadSignature},
+		{"missing signature", VexoraSignature, []string{"s3cret"}, "", "1700000000", ErrBadSignature},
+		{"missing timestamp", VexoraSignature, []string{"s3cret"}, sig, "", ErrBadSignature},
+		{"timestamp not signed", VexoraSignature, []string{"s3cret"}, sig, "1700000001", ErrBadSignature},
+		{"too old", VexoraSignature, []string{"s3cret"}, Sign("s3cret", now.Add(-6*time.Minute), body), "1699999640", ErrStaleTimestamp},
+		{"too far ahead", VexoraSignature, []string{"s3cret"}, Sign("s3cret", now.Add(6*time.Minute), body), "1700000360", ErrStaleTimestamp},
+		{"within skew", VexoraSignature, []string{"s3cret"}, Sign("s3cret", now.Add(-4*time.Minute), body), "1699999760", nil},
+		{"body only", plain, []string{"s3cret"}, "sha256=" + plainSig, "", nil},
+		{"body only, bare uppercase", plain, []string{"s3cret"}, strings.ToUpper(plainSig), "", nil},
+		{"token", Signature{Header: "X-Gitlab-Token", Token: true}, []string{"s3cret"}, "s3cret", "", nil},
+		{"wrong token", Signature{Header: "X-Gitlab-Token", Token: true}, []string{"s3cret"}, "S3CRET", "", ErrBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(tt.scheme.Header, tt.sig)
+			if tt.scheme.Timestamp != "" && tt.ts != "" {
+				h.Set(tt.scheme.Timestamp, tt.ts)
+			}
+			err := tt.scheme.Verify(tt.secrets, body, h, now, 5*time.Minute)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSignatureReplay(t *testing.T) {
+	cfg := SignatureConfig{Signature: VexoraSignature, Secrets: []string{"s3cret"}, Nonces: NewNonceCache(10)}
+	body := `{"notes":"shipped"}`
+	now := time.Now()
+	sig := Sign("s3cret", now, []byte(body))
+	bare := strings.TrimPrefix(sig, "sha256=")
+
+	tests := []struct {
+		name string
+		sig  string
+		want int
+	}{
+		{"first delivery", sig, 200},
+		{"replay", sig, http.StatusConflict},
+		{"replay without prefix", bare, http.StatusConflict},
+		{"replay in uppercase", "sha256=" + strings.ToUpper(bare), http.StatusConflict},
+		{"forged", "sha256=" + strings.Repeat("0", 64), 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/hooks/vexora", strings.NewReader(body))
+			r.Header.Set("X-Vexora-Signature", tt.sig)
+			r.Header.Set("X-Vexora-Timestamp", strconv.FormatInt(now.Unix(), 10))
+			w := httptest.NewRecorder()
+			got, ok := CheckSignature(w, r, cfg)
+			if ok {
+				w.WriteHeader(200)
+				if string(got) != body {
+					t.Errorf("body = %q", got)
+				}
+			}
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayKey(t *testing.T) {
+	body := []byte("payload")
+	h := http.Header{}
+	h.Set("X-Vexora-Signature", "sha256=ABCDEF")
+	if got := VexoraSignature.ReplayKey(h, body); got != "abcdef" {
+		t.Errorf("timestamped key = %q, want the normalized MAC", got)
+	}
+
+	// Untimestamped schemes key on the body, whatever the delivery headers
+	plain := Signature{Header: "X-Hub-Signature-256"}
+	other := http.Header{}
+	other.Set("X-Hub-Signature-256", "sha256=abcdef")
+	if plain.ReplayKey(h, body) != plain.ReplayKey(other, body) {
+		t.Error("same body gave different keys")
+	}
+	if plain.ReplayKey(h, body) == plain.ReplayKey(h, []byte("other")) {
+		t.Error("different bodies gave the same key")
+	}
+}
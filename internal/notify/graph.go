@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/microsoft"
+)
+
+// GraphConfig is an Azure app registration allowed to send mail (the
+// Mail.Send application permission) as Sender
+type GraphConfig struct {
+	TenantID     string `json:"tenant_id"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Sender       string `json:"sender"`    // mailbox the mail is sent from
+	BaseURL      string `json:"base_url"`  // default https://graph.microsoft.com/v1.0
+	TokenURL     string `json:"token_url"` // default the tenant's Azure AD token endpoint
+}
+
+type graphNotifier struct {
+	cfg    GraphConfig
+	client *http.Client // adds the app's token, fetched once and renewed when it expires
+}
+
+// NewGraph checks cfg and returns a notifier sending through Microsoft Graph
+func NewGraph(cfg GraphConfig) (Notifier, error) {
+	if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.Sender == "" {
+		return nil, errors.New("graph: tenant_id, client_id, client_secret and sender are required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://graph.microsoft.com/v1.0"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = microsoft.AzureADEndpoint(cfg.TenantID).TokenURL
+	}
+	oauth := &clientcredentials.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		TokenURL:     cfg.TokenURL,
+		Scopes:       []string{"https://graph.microsoft.com/.default"},
+	}
+	tokens := oauth2.ReuseTokenSource(nil, oauth.TokenSource(context.Background()))
+	return &graphNotifier{cfg: cfg, client: oauth2.NewClient(context.Background(), tokens)}, nil
+}
+
+type graphRecipient struct {
+	EmailAddress struct {
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+type graphMessage struct {
+	Message struct {
+		Subject string `json:"subject"`
+		Body    struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		} `json:"body"`
+		ToRecipients []graphRecipient `json:"toRecipients"`
+	} `json:"message"`
+	SaveToSentItems bool `json:"saveToSentItems"`
+}
+
+func (n *graphNotifier) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("graph: no recipients")
+	}
+	var req graphMessage
+	req.Message.Subject = singleLine(msg.Subject)
+	req.Message.Body.ContentType, req.Message.Body.Content = "Text", msg.Text
+	if msg.HTML != "" {
+		req.Message.Body.ContentType, req.Message.Body.Content = "HTML", msg.HTML
+	}
+	for _, addr := range msg.To {
+		var r graphRecipient
+		r.EmailAddress.Address = addr
+		req.Message.ToRecipients = append(req.Message.ToRecipients, r)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(n.cfg.BaseURL, "/") + "/users/" + url.PathEscape(n.cfg.Sender) + "/sendMail"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("graph: sendMail returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGraph serves the token endpoint and sendMail, answering sendMail with status
+func fakeGraph(t *testing.T, status int) (cfg GraphConfig, tokens, mails *atomic.Int32) {
+	t.Helper()
+	tokens, mails = new(atomic.Int32), new(atomic.Int32)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "client_credentials" {
+			t.Errorf("grant_type = %q", r.FormValue("grant_type"))
+		}
+		tokens.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "graph-token", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("POST /users/{sender}/sendMail", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer graph-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.PathValue("sender"); got != "studio@example.com" {
+			t.Errorf("sender = %q", got)
+		}
+		var msg graphMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil || msg.Message.Subject != "Review needed" {
+			t.Errorf("message = %+v, %v", msg, err)
+		}
+		mails.Add(1)
+		w.WriteHeader(status)
+		w.Write([]byte("mailbox not found"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg = GraphConfig{TenantID: "tenant", ClientID: "id", ClientSecret: "secret", Sender: "studio@example.com",
+		BaseURL: srv.URL, TokenURL: srv.URL + "/token"}
+	return cfg, tokens, mails
+}
+
+func TestGraphSend(t *testing.T) {
+	cfg, tokens, mails := fakeGraph(t, http.StatusAccepted)
+	n, err := NewGraph(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{To: []string{"reviewer@example.com"}, Subject: "Review needed", Text: "Job #1 is waiting."}
+	for range 3 {
+		if err := n.Send(context.Background(), msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if mails.Load() != 3 {
+		t.Errorf("%d mails sent, want 3", mails.Load())
+	}
+	if tokens.Load() != 1 {
+		t.Errorf("%d tokens fetched, want 1 reused for every send", tokens.Load())
+	}
+}
+
+func TestGraphSendRejected(t *testing.T) {
+	cfg, _, _ := fakeGraph(t, http.StatusNotFound)
+	n, err := NewGraph(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Send(context.Background(), Message{To: []string{"reviewer@example.com"}, Subject: "Review needed", Text: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "mailbox not found") {
+		t.Errorf("Send = %v, want the 404 and its body", err)
+	}
+}
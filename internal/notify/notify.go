@@ -0,0 +1,117 @@
+// Package notify emails reviewers when a job is waiting for approval.
+//
+// A Notifier sends a rendered Message through a backend: plain SMTP or
+// Microsoft Graph. Both are configured from a JSON file (NOTIFY_CONFIG)
+// and the environment, which takes precedence.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Message is a rendered email. HTML is optional; Text is always sent.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier delivers messages through one backend
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Backends
+const (
+	BackendNone  = "none"
+	BackendSMTP  = "smtp"
+	BackendGraph = "graph"
+)
+
+// Config selects and configures the backend
+type Config struct {
+	Backend   string      `json:"backend"`   // smtp, graph or none (default)
+	Reviewers []string    `json:"reviewers"` // told about every job waiting for approval
+	SMTP      SMTPConfig  `json:"smtp"`
+	Graph     GraphConfig `json:"graph"`
+}
+
+// LoadConfig reads the JSON file named by NOTIFY_CONFIG, if any, then
+// overrides it with NOTIFY_BACKEND, NOTIFY_REVIEWERS (comma-separated),
+// SMTP_* and GRAPH_*
+func LoadConfig() (Config, error) {
+	var cfg Config
+	if path := os.Getenv("NOTIFY_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	env(&cfg.Backend, "NOTIFY_BACKEND")
+	if v := os.Getenv("NOTIFY_REVIEWERS"); v != "" {
+		cfg.Reviewers = nil
+		for _, addr := range strings.Split(v, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				cfg.Reviewers = append(cfg.Reviewers, addr)
+			}
+		}
+	}
+
+	env(&cfg.SMTP.Host, "SMTP_HOST")
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("SMTP_PORT: %w", err)
+		}
+		cfg.SMTP.Port = port
+	}
+	env(&cfg.SMTP.Username, "SMTP_USERNAME")
+	env(&cfg.SMTP.Password, "SMTP_PASSWORD")
+	env(&cfg.SMTP.From, "SMTP_FROM")
+	env(&cfg.SMTP.TLS, "SMTP_TLS")
+
+	env(&cfg.Graph.TenantID, "GRAPH_TENANT_ID")
+	env(&cfg.Graph.ClientID, "GRAPH_CLIENT_ID")
+	env(&cfg.Graph.ClientSecret, "GRAPH_CLIENT_SECRET")
+	env(&cfg.Graph.Sender, "GRAPH_SENDER")
+	env(&cfg.Graph.BaseURL, "GRAPH_BASE_URL")
+	env(&cfg.Graph.TokenURL, "GRAPH_TOKEN_URL")
+
+	for _, addr := range cfg.Reviewers {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return cfg, fmt.Errorf("reviewer %q: %w", addr, err)
+		}
+	}
+	return cfg, nil
+}
+
+func env(field *string, key string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*field = v
+	}
+}
+
+// New builds the configured notifier; nil when notifications are off
+func New(cfg Config) (Notifier, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendNone:
+		return nil, nil
+	case BackendSMTP:
+		return NewSMTP(cfg.SMTP)
+	case BackendGraph:
+		return NewGraph(cfg.Graph)
+	default:
+		return nil, fmt.Errorf("unknown notify backend %q (want smtp, graph or none)", cfg.Backend)
+	}
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"vexora-studio/internal/approval"
+	"vexora-studio/internal/database"
+)
+
+// ApprovalData is what the waiting_approval template sees
+type ApprovalData struct {
+	Job        database.QueueItem
+	ApproveURL string // signed link to the confirmation page, see approval.Link
+	RejectURL  string
+	ExpiresAt  time.Time
+}
+
+// ApprovalMessage renders the reviewer email for a job waiting for approval
+func ApprovalMessage(job database.QueueItem, to []string) (Message, error) {
+	expires := job.ApprovalExpiry()
+	return Render(TemplateWaitingApproval, ApprovalData{
+		Job:        job,
+		ApproveURL: approval.Link(job.ApprovalToken, job.ID, approval.ActionApprove, expires),
+		RejectURL:  approval.Link(job.ApprovalToken, job.ID, approval.ActionReject, expires),
+		ExpiresAt:  expires,
+	}, to)
+}
+
+var wake = make(chan struct{}, 1)
+
+// Wake makes the reviewer notifier look for new jobs now
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartReviewers emails reviewers about every job that reaches
+// WAITING_APPROVAL, until ctx is cancelled. A job is marked once sent;
+// failures are retried on the next round.
+func StartReviewers(ctx context.Context, n Notifier, reviewers []string) {
+	go func() {
+		poll := pollInterval()
+		for {
+			notifyWaiting(ctx, n, reviewers)
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-time.After(poll):
+			}
+		}
+	}()
+	log.Printf("📧 Notifying %d reviewers of jobs waiting for approval", len(reviewers))
+}
+
+func notifyWaiting(ctx context.Context, n Notifier, reviewers []string) {
+	jobs, err := database.GetJobsToNotify()
+	if err != nil {
+		log.Printf("❌ Notifier: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		msg, err := ApprovalMessage(job, reviewers)
+		if err != nil {
+			log.Printf("❌ Notifier: job #%d: failed to render message: %v", job.ID, err)
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = n.Send(sendCtx, msg)
+		cancel()
+		if err != nil {
+			// Most likely the mail server is down; try again next round
+			log.Printf("❌ Notifier: job #%d: %v", job.ID, err)
+			return
+		}
+		if err := database.MarkNotified(job.ID); err != nil {
+			log.Printf("❌ Notifier: failed to mark job #%d: %v", job.ID, err)
+		}
+		log.Printf("📧 Job #%d: reviewers notified", job.ID)
+	}
+}
+
+func pollInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("NOTIFY_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP connection security
+const (
+	TLSStartTLS = "starttls" // upgrade a plain connection, usually port 587
+	TLSImplicit = "implicit" // TLS from the start, usually port 465
+	TLSNone     = "none"     // plain text, for a local sink such as Mailpit
+)
+
+// SMTPConfig is a mail server. Port defaults to 587, 465 for implicit TLS
+// and 25 without TLS.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"` // no username skips AUTH
+	Password string `json:"password"`
+	From     string `json:"from"`
+	TLS      string `json:"tls"` // starttls (default), implicit or none
+}
+
+type smtpNotifier struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+// NewSMTP checks cfg and returns a notifier sending through it
+func NewSMTP(cfg SMTPConfig) (Notifier, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp: host is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: from: %w", err)
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	if cfg.Port == 0 {
+		switch cfg.TLS {
+		case TLSImplicit:
+			cfg.Port = 465
+		case TLSNone:
+			cfg.Port = 25
+		default:
+			cfg.Port = 587
+		}
+	}
+	switch cfg.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("smtp: unknown tls mode %q (want starttls, implicit or none)", cfg.TLS)
+	}
+	return &smtpNotifier{cfg: cfg, from: from}, nil
+}
+
+func (n *smtpNotifier) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("smtp: no recipients")
+	}
+	body, rcpt, err := n.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host}
+	if n.cfg.TLS == TLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if n.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp: server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp: starttls: %w", err)
+		}
+	}
+	if n.cfg.Username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := c.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	for _, to := range rcpt {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp: recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return c.Quit()
+}
+
+// compose builds the RFC 5322 message, text only or multipart/alternative
+// with an HTML part, and returns it with the bare recipient addresses
+func (n *smtpNotifier) compose(msg Message) ([]byte, []string, error) {
+	to := make([]string, len(msg.To))
+	rcpt := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("smtp: recipient %q: %w", addr, err)
+		}
+		to[i], rcpt[i] = a.String(), a.Address
+	}
+
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", n.from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", singleLine(msg.Subject)))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(n.from.Address))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		err := writeQP(&buf, msg.Text)
+		return buf.Bytes(), rcpt, err
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range [][2]string{{"text/plain", msg.Text}, {"text/html", msg.HTML}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0] + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQP(pw, part[1]); err != nil {
+			return nil, nil, err
+		}
+	}
+	err := mw.Close()
+	return buf.Bytes(), rcpt, err
+}
+
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// singleLine keeps a rendered subject from injecting headers
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func messageID(from string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	domain := "vexora.local"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what the fake server received
+type smtpSession struct {
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTP accepts one plain-text session on a local port and sends what
+// it received on the returned channel
+func fakeSMTP(t *testing.T) (port int, got <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		c := textproto.NewConn(conn)
+		var s smtpSession
+		c.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				c.PrintfLine("250 fake")
+			case "MAIL":
+				s.from = arg
+				c.PrintfLine("250 OK")
+			case "RCPT":
+				s.rcpt = append(s.rcpt, arg)
+				c.PrintfLine("250 OK")
+			case "DATA":
+				c.PrintfLine("354 go ahead")
+				data, err := io.ReadAll(c.DotReader())
+				if err != nil {
+					return
+				}
+				s.data = string(data)
+				c.PrintfLine("250 queued")
+			case "QUIT":
+				c.PrintfLine("221 bye")
+				ch <- s
+				return
+			default:
+				c.PrintfLine("502 unknown command")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestSMTPSend(t *testing.T) {
+	port, got := fakeSMTP(t)
+	n, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port, From: "Vexora <bot@example.com>", TLS: TLSNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Send(t.Context(), Message{
+		To:      []string{"Ada <ada@example.com>", "bob@example.com"},
+		Subject: "Review ready:\r\nBcc: evil@example.com",
+		Text:    "Job #7 is waiting — approve it",
+		HTML:    "<p>Job #7 is waiting</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	s := <-got
+
+	if s.from != "FROM:<bot@example.com>" {
+		t.Errorf("MAIL %s", s.from)
+	}
+	if want := []string{"TO:<ada@example.com>", "TO:<bob@example.com>"}; strings.Join(s.rcpt, ",") != strings.Join(want, ",") {
+		t.Errorf("RCPT %v, want %v", s.rcpt, want)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	var dec mime.WordDecoder
+	subject, _ := dec.DecodeHeader(m.Header.Get("Subject"))
+	for k, want := range map[string]string{
+		"From":         `"Vexora" <bot@example.com>`,
+		"To":           `"Ada" <ada@example.com>, <bob@example.com>`,
+		"MIME-Version": "1.0",
+		"Bcc":          "",
+	} {
+		if got := m.Header.Get(k); got != want {
+			t.Errorf("%s: %q, want %q", k, got, want)
+		}
+	}
+	if subject != "Review ready: Bcc: evil@example.com" {
+		t.Errorf("Subject: %q", subject)
+	}
+	if !strings.HasSuffix(m.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID: %q", m.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type: %q, %v", m.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for _, want := range [][2]string{
+		{"text/plain", "Job #7 is waiting — approve it"},
+		{"text/html", "<p>Job #7 is waiting</p>"},
+	} {
+		p, err := mr.NextPart() // decodes the quoted-printable
+		if err != nil {
+			t.Fatalf("%s part: %v", want[0], err)
+		}
+		if ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type")); ct != want[0] {
+			t.Errorf("part type %q, want %q", ct, want[0])
+		}
+		body, _ := io.ReadAll(p)
+		if string(body) != want[1] {
+			t.Errorf("%s part: %q, want %q", want[0], body, want[1])
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("extra part: %v", err)
+	}
+}
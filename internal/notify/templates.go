@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Message templates. <name>.tmpl defines the "subject" and "text" blocks;
+// the optional <name>.html is the HTML body. NOTIFY_TEMPLATES_DIR can hold
+// replacements for either file.
+const (
+	TemplateWaitingApproval = "waiting_approval"
+)
+
+//go:embed templates/*
+var builtin embed.FS
+
+func readTemplate(file string) ([]byte, error) {
+	if dir := os.Getenv("NOTIFY_TEMPLATES_DIR"); dir != "" {
+		if body, err := os.ReadFile(filepath.Join(dir, file)); err == nil {
+			return body, nil
+		}
+	}
+	return builtin.ReadFile("templates/" + file)
+}
+
+// Render fills the named template with data, addressed to to
+func Render(name string, data any, to []string) (Message, error) {
+	msg := Message{To: to}
+
+	src, err := readTemplate(name + ".tmpl")
+	if err != nil {
+		return msg, err
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return msg, err
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return msg, err
+	}
+	msg.Subject = singleLine(buf.String())
+	buf.Reset()
+	if err := t.ExecuteTemplate(&buf, "text", data); err != nil {
+		return msg, err
+	}
+	msg.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	src, err = readTemplate(name + ".html")
+	if errors.Is(err, fs.ErrNotExist) {
+		return msg, nil
+	}
+	if err != nil {
+		return msg, err
+	}
+	h, err := htmltemplate.New(name).Parse(string(src))
+	if err != nil {
+		return msg, err
+	}
+	buf.Reset()
+	if err := h.Execute(&buf, data); err != nil {
+		return msg, err
+	}
+	msg.HTML = buf.String()
+	return msg, nil
+}
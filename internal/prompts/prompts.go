@@ -0,0 +1,182 @@
+// Package prompts resolves and renders the system prompts sent to the LLM.
+//
+// Every template exists as a built-in default (defaults/*.tmpl, or
+// PROMPTS_DIR/<name>.tmpl when set). Versions stored in the database take
+// precedence: a project's own version first, then the global one.
+package prompts
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"vexora-studio/internal/database"
+)
+
+// Template names used by the platforms
+const (
+	Twitter        = "twitter"
+	TwitterThread  = "twitter_thread"
+	LinkedIn       = "linkedin"
+	Instagram      = "instagram"
+	NewsletterMeta = "newsletter_meta"
+	NewsletterBody = "newsletter_body"
+)
+
+// Template sources
+const (
+	SourceBuiltin = "builtin" // embedded default
+	SourceFile    = "file"    // PROMPTS_DIR
+	SourceDB      = "db"
+)
+
+var ErrUnknownTemplate = errors.New("unknown prompt template")
+
+//go:embed defaults/*.tmpl
+var defaults embed.FS
+
+// Data holds the variables available to templates
+type Data struct {
+	Project  string
+	Voice    string
+	Audience string
+	Hashtags []string
+	Footer   string
+	Tweets   int    // thread length, 0 lets the model decide
+	CTA      string // what the last tweet of a thread asks for
+}
+
+// sample is used to check that a template renders before storing it
+var sample = Data{Project: "vexora", Voice: "casual", Audience: "Go developers", Hashtags: []string{"#golang"}, Footer: "via Vexora ⚡", Tweets: 5, CTA: "Star the repo"}
+
+var funcs = template.FuncMap{"join": strings.Join}
+
+// Template is the resolved body of a prompt and where it came from
+type Template struct {
+	Name      string `json:"name"`
+	Project   string `json:"project,omitempty"`
+	Version   int    `json:"version"` // 0 for built-in and file templates
+	Source    string `json:"source"`
+	Body      string `json:"body"`
+	Author    string `json:"author,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// ID is the prompt version recorded with every output, e.g. "twitter@v3",
+// "twitter@v2[acme]" or "twitter@builtin-1a2b3c4d"
+func (t Template) ID() string {
+	if t.Source != SourceDB {
+		sum := sha256.Sum256([]byte(t.Body))
+		return fmt.Sprintf("%s@%s-%s", t.Name, t.Source, hex.EncodeToString(sum[:4]))
+	}
+	id := fmt.Sprintf("%s@v%d", t.Name, t.Version)
+	if t.Project != "" {
+		id += "[" + t.Project + "]"
+	}
+	return id
+}
+
+// Names lists every template, sorted
+func Names() []string {
+	entries, _ := defaults.ReadDir("defaults")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// IsName reports whether name is a known template
+func IsName(name string) bool {
+	return slices.Contains(Names(), name)
+}
+
+// Builtin returns the default for name, from PROMPTS_DIR if it has one
+func Builtin(name string) (Template, error) {
+	if !IsName(name) {
+		return Template{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		if body, err := os.ReadFile(filepath.Join(dir, name+".tmpl")); err == nil {
+			return Template{Name: name, Source: SourceFile, Body: string(body)}, nil
+		}
+	}
+	body, err := defaults.ReadFile("defaults/" + name + ".tmpl")
+	if err != nil {
+		return Template{}, err
+	}
+	return Template{Name: name, Source: SourceBuiltin, Body: string(body)}, nil
+}
+
+// Resolve picks the template used for project: its own stored version,
+// else the global stored version, else the built-in default
+func Resolve(name, project string) (Template, error) {
+	if !IsName(name) {
+		return Template{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	if database.DB != nil {
+		scopes := []string{""}
+		if project != "" {
+			scopes = []string{project, ""}
+		}
+		for _, scope := range scopes {
+			p, err := database.LatestPrompt(name, scope)
+			if err == nil {
+				return FromDB(p), nil
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return Template{}, err
+			}
+		}
+	}
+	return Builtin(name)
+}
+
+// FromDB converts a stored version
+func FromDB(p *database.PromptTemplate) Template {
+	return Template{
+		Name:      p.Name,
+		Project:   p.ProjectName,
+		Version:   p.Version,
+		Source:    SourceDB,
+		Body:      p.Body,
+		Author:    p.Author,
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+// Validate parses body and renders it with sample data
+func Validate(name, body string) error {
+	t, err := template.New(name).Funcs(funcs).Parse(body)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	return t.Execute(&sb, sample)
+}
+
+// Render resolves name for data.Project and executes it. The returned ID
+// identifies the exact template version used.
+func Render(name string, data Data) (string, string, error) {
+	tpl, err := Resolve(name, data.Project)
+	if err != nil {
+		return "", "", err
+	}
+	t, err := template.New(name).Funcs(funcs).Parse(tpl.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("prompt %s: %w", tpl.ID(), err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("prompt %s: %w", tpl.ID(), err)
+	}
+	return strings.TrimSpace(sb.String()), tpl.ID(), nil
+}
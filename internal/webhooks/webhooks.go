@@ -0,0 +1,269 @@
+// Package webhooks sends content lifecycle events to subscribed URLs.
+// Deliveries are logged in webhook_deliveries, signed the way
+// middleware.ValidateSignature checks and retried with exponential backoff.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/middleware"
+)
+
+// Events a webhook can subscribe to
+const (
+	ContentGenerated = "content.generated" // a feed was generated, by a job or directly
+	ContentApproved  = "content.approved"  // a reviewer approved a job
+	ContentFailed    = "content.failed"    // a job gave up and needs a human
+	JobRetry         = "job.retry"         // a job was scheduled for another attempt
+)
+
+// Events lists every event
+var Events = []string{ContentGenerated, ContentApproved, ContentFailed, JobRetry}
+
+// IsEvent reports whether name is a known event
+func IsEvent(name string) bool {
+	return slices.Contains(Events, name)
+}
+
+// envelope is the JSON body of every delivery
+type envelope struct {
+	Event     string    `json:"event"`
+	Project   string    `json:"project,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// wake nudges the dispatcher when deliveries are queued
+var wake = make(chan struct{}, 1)
+
+// Emit queues event for every subscription to it and wakes the dispatcher.
+// Failures are logged, never returned: a webhook must not fail the request
+// that triggered it.
+func Emit(event, project string, data any) {
+	project = database.Slugify(project)
+	payload, err := json.Marshal(envelope{Event: event, Project: project, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("❌ Webhook %s: failed to encode payload: %v", event, err)
+		return
+	}
+	n, err := database.EnqueueWebhookDeliveries(event, project, payload)
+	if err != nil {
+		log.Printf("❌ Webhook %s: failed to queue deliveries: %v", event, err)
+		return
+	}
+	if n > 0 {
+		log.Printf("📤 Queued %s for %d webhooks", event, n)
+		Wake()
+	}
+}
+
+// Wake makes the dispatcher look for due deliveries now
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// EmitJob emits event with the job's current state under "job", next to fields
+func EmitJob(event string, jobID int64, fields map[string]any) {
+	job, err := database.GetEntry(jobID)
+	if err != nil {
+		log.Printf("❌ Webhook %s: failed to load job #%d: %v", event, jobID, err)
+		return
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["job"] = job
+	Emit(event, job.ProjectName, fields)
+}
+
+// Start runs the dispatcher until ctx is cancelled
+func Start(ctx context.Context) {
+	go run(ctx)
+	log.Printf("📤 Started webhook dispatcher")
+}
+
+// batchSize is how many due deliveries of a webhook are read at a time
+const batchSize = 50
+
+// run starts a sender for every webhook with due deliveries, at most
+// WEBHOOK_CONCURRENCY at a time. Each webhook gets its deliveries in order
+// from a single sender, so a slow receiver only holds up its own.
+func run(ctx context.Context) {
+	poll := pollInterval()
+	slots := make(chan struct{}, concurrency())
+	var mu sync.Mutex
+	busy := map[int64]bool{} // webhooks with a sender running
+	for {
+		ids, err := database.DueWebhooks()
+		if err != nil {
+			log.Printf("❌ Webhook dispatcher: %v", err)
+		}
+		for _, id := range ids {
+			if len(slots) == cap(slots) {
+				break // a sender finishing wakes us for the rest
+			}
+			mu.Lock()
+			idle := !busy[id]
+			busy[id] = true
+			mu.Unlock()
+			if !idle {
+				continue
+			}
+
+			slots <- struct{}{}
+			go func() {
+				drain(ctx, id)
+				mu.Lock()
+				delete(busy, id)
+				mu.Unlock()
+				<-slots
+				Wake()
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(poll):
+		}
+	}
+}
+
+// drain delivers a webhook's due deliveries until none are left
+func drain(ctx context.Context, webhookID int64) {
+	for ctx.Err() == nil {
+		due, err := database.DueWebhookDeliveries(webhookID, batchSize)
+		if err != nil {
+			log.Printf("❌ Webhook dispatcher: %v", err)
+			return
+		}
+		for _, d := range due {
+			deliver(ctx, d)
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// client doesn't follow redirects: a receiver that moved answers 3xx,
+// which counts as a failure until the subscription is updated
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// deliver makes one attempt and schedules the next if it fails
+func deliver(ctx context.Context, d database.WebhookDelivery) {
+	hook, err := database.GetWebhook(d.WebhookID)
+	if err != nil {
+		log.Printf("❌ Webhook delivery #%d: failed to load webhook #%d: %v", d.ID, d.WebhookID, err)
+		database.RecordWebhookAttempt(d.ID, false, 0, "", "webhook not found", 0)
+		return
+	}
+	if !hook.Active {
+		database.RecordWebhookAttempt(d.ID, false, 0, "", "webhook is inactive", 0)
+		return
+	}
+
+	code, body, err := send(ctx, hook, d)
+	ok := err == nil && code >= 200 && code < 300
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	} else if !ok {
+		errMsg = "receiver answered " + strconv.Itoa(code)
+	}
+
+	var retryIn time.Duration
+	attempt := d.Attempts + 1
+	if !ok && attempt < maxAttempts() {
+		retryIn = backoff(attempt)
+		log.Printf("⏳ Webhook delivery #%d (%s to %s) failed, retrying in %s: %s", d.ID, d.Event, hook.URL, retryIn, errMsg)
+	} else if !ok {
+		log.Printf("🛑 Webhook delivery #%d (%s to %s) failed %d times, giving up: %s", d.ID, d.Event, hook.URL, attempt, errMsg)
+	}
+	if err := database.RecordWebhookAttempt(d.ID, ok, code, body, errMsg, retryIn); err != nil {
+		log.Printf("❌ Webhook delivery #%d: failed to record attempt: %v", d.ID, err)
+	}
+}
+
+// send posts the payload and returns the status and the start of the response body
+func send(ctx context.Context, hook *database.Webhook, d database.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Vexora-Studio-Webhooks")
+	req.Header.Set("X-Vexora-Event", d.Event)
+	req.Header.Set("X-Vexora-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set(middleware.VexoraSignature.Timestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(middleware.VexoraSignature.Header, middleware.Sign(hook.Secret, now, d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return resp.StatusCode, string(body), nil
+}
+
+// backoff doubles WEBHOOK_BACKOFF_BASE (default 30s) per attempt, capped at
+// an hour. It is at least a second: an attempt in the same second would
+// carry the same signature, which receivers reject as a replay.
+func backoff(attempt int) time.Duration {
+	base := 30 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("WEBHOOK_BACKOFF_BASE")); err == nil && d >= time.Second {
+		base = d
+	}
+	delay := base << (attempt - 1)
+	if limit := time.Hour; delay > limit || delay <= 0 {
+		delay = limit
+	}
+	return delay
+}
+
+// maxAttempts reads WEBHOOK_MAX_ATTEMPTS, defaulting to 8
+func maxAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && n > 0 {
+		return n
+	}
+	return 8
+}
+
+// concurrency reads WEBHOOK_CONCURRENCY, how many webhooks are sent to at
+// the same time (default 4)
+func concurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_CONCURRENCY")); err == nil && n > 0 {
+		return n
+	}
+	return 4
+}
+
+func pollInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("WEBHOOK_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Second
+}
@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"vexora-studio/internal/database"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// subscribe adds an active webhook for ContentGenerated posting to url
+func subscribe(t *testing.T, url string) int64 {
+	t.Helper()
+	h := &database.Webhook{URL: url, Events: []string{ContentGenerated}, Active: true}
+	if err := database.InsertWebhook(h); err != nil {
+		t.Fatal(err)
+	}
+	return h.ID
+}
+
+// A receiver that takes its time must not hold up the others
+func TestSlowReceiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency string
+		fastWaits   bool
+	}{
+		{"senders run side by side", "4", false},
+		{"one sender at a time", "1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir()) // Open creates ./data
+			t.Setenv("VEXORA_DB_BACKUP", "0")
+			t.Setenv("WEBHOOK_CONCURRENCY", tt.concurrency)
+			t.Setenv("WEBHOOK_POLL_INTERVAL", "50ms")
+			if err := database.Init(filepath.Join(t.TempDir(), "test.db")); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { database.Close() })
+
+			release := make(chan struct{})
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+			defer slow.Close()
+			fastGot := make(chan string, 2)
+			fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fastGot <- r.Header.Get("X-Vexora-Delivery")
+			}))
+			defer fast.Close()
+
+			slowID := subscribe(t, slow.URL) // first in line
+			fastID := subscribe(t, fast.URL)
+			Emit(ContentGenerated, "", map[string]any{"n": 1})
+			Emit(ContentGenerated, "", map[string]any{"n": 2})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go run(ctx)
+
+			received := func(wait time.Duration) int {
+				n := 0
+				for timeout := time.After(wait); n < 2; n++ {
+					select {
+					case <-fastGot:
+					case <-timeout:
+						return n
+					}
+				}
+				return n
+			}
+			if tt.fastWaits {
+				if n := received(300 * time.Millisecond); n != 0 {
+					t.Fatalf("fast receiver got %d deliveries past the concurrency limit", n)
+				}
+				close(release)
+				if n := received(5 * time.Second); n != 2 {
+					t.Fatalf("fast receiver got %d deliveries after the slow one, want 2", n)
+				}
+			} else {
+				if n := received(5 * time.Second); n != 2 {
+					t.Fatalf("fast receiver got %d deliveries while the slow one hung, want 2", n)
+				}
+				close(release)
+			}
+
+			for _, id := range []int64{fastID, slowID} {
+				waitDelivered(t, id, 2)
+			}
+		})
+	}
+}
+
+// waitDelivered waits until n deliveries of the webhook are delivered
+func waitDelivered(t *testing.T, webhookID int64, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		done, err := database.ListWebhookDeliveries(webhookID, database.DeliveryDelivered, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(done) == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook #%d: %d of %d deliveries delivered", webhookID, len(done), n)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vexora-studio/internal/approval"
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+	"vexora-studio/internal/notify"
+	"vexora-studio/internal/webhooks"
+)
+
+// Start launches n workers that drain journal_entries until ctx is cancelled.
+// The returned WaitGroup is done once every worker has stopped.
+func Start(ctx context.Context, n int) *sync.WaitGroup {
+	if released, err := database.ReleaseStaleJobs(); err != nil {
+		log.Printf("❌ Worker: failed to release stale jobs: %v", err)
+	} else if released > 0 {
+		log.Printf("♻️ Worker: re-queued %d jobs left in PROCESSING", released)
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			run(ctx, id)
+		}(i)
+	}
+	log.Printf("👷 Started %d queue workers", n)
+	return &wg
+}
+
+func run(ctx context.Context, id int) {
+	poll := pollInterval()
+	for {
+		// Drain everything that is runnable before sleeping again
+		for ctx.Err() == nil {
+			job, err := database.ClaimNextJob()
+			if err != nil {
+				log.Printf("❌ Worker %d: claim failed: %v", id, err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			process(id, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+	}
+}
+
+func process(workerID int, job *database.QueueItem) {
+	log.Printf("⚙️ Worker %d: processing job #%d (%s, attempt %d/%d)", workerID, job.ID, job.FeedType, job.AttemptCount+1, job.MaxAttempts)
+
+	if !llm.IsFeedType(job.FeedType) {
+		log.Printf("❌ Worker %d: job #%d has unsupported feed type %q", workerID, job.ID, job.FeedType)
+		if err := database.MarkFailed(job.ID, "unsupported feed type: "+job.FeedType); err != nil {
+			log.Printf("❌ Worker %d: failed to mark job #%d: %v", workerID, job.ID, err)
+			return
+		}
+		webhooks.EmitJob(webhooks.ContentFailed, job.ID, map[string]any{"error": "unsupported feed type: " + job.FeedType})
+		return
+	}
+
+	in := llm.Input{Notes: job.RawNotes, Project: database.Slugify(job.ProjectName)}
+	res, err := llm.GenerateContent(job.FeedType, in, llm.Options{})
+	if err != nil {
+		fail(workerID, job, err)
+		return
+	}
+
+	subject, content, tags := splitOutput(job.FeedType, res.Text)
+	token, err := approval.NewToken()
+	if err != nil {
+		fail(workerID, job, err)
+		return
+	}
+
+	feed := &database.Content{
+		Platform:    job.FeedType,
+		ProjectName: job.ProjectName,
+		RawNotes:    job.RawNotes,
+		Output:      res.Text,
+		Provider:    res.Provider,
+		Model:       res.Model,
+		LatencyMS:   res.Latency.Milliseconds(),
+		Status:      database.ContentWaitingApproval,
+
+		PromptVersion: res.PromptVersion,
+
+		PromptTokens:     res.Usage.PromptTokens,
+		CompletionTokens: res.Usage.CompletionTokens,
+		CostUSD:          res.Usage.CostUSD,
+	}
+	if res.Metadata != nil {
+		feed.Metadata, _ = json.Marshal(res.Metadata)
+	}
+	feed.Params, _ = json.Marshal(res.Params)
+	expires := time.Now().Add(approval.TTL())
+	if err := database.SetApprovalWait(job.ID, feed, subject, content, tags, token, expires); err != nil {
+		log.Printf("❌ Worker %d: failed to save job #%d: %v", workerID, job.ID, err)
+		fail(workerID, job, err)
+		return
+	}
+	log.Printf("✅ Worker %d: job #%d is waiting for approval (%s)", workerID, job.ID, res.Provider)
+	notify.Wake()
+	webhooks.Emit(webhooks.ContentGenerated, job.ProjectName, map[string]any{"content": feed, "job_id": job.ID})
+}
+
+// fail either schedules another attempt with exponential backoff or,
+// once max attempts is reached, parks the job for a human.
+func fail(workerID int, job *database.QueueItem, cause error) {
+	attempt := job.AttemptCount + 1
+	if attempt >= job.MaxAttempts {
+		log.Printf("🛑 Worker %d: job #%d failed %d times, needs a human: %v", workerID, job.ID, attempt, cause)
+		if err := database.MarkRetry(job.ID, cause.Error()); err != nil {
+			log.Printf("❌ Worker %d: failed to mark job #%d: %v", workerID, job.ID, err)
+			return
+		}
+		webhooks.EmitJob(webhooks.ContentFailed, job.ID, map[string]any{"error": cause.Error()})
+		return
+	}
+
+	delay := backoff(attempt)
+	log.Printf("⏳ Worker %d: job #%d failed (attempt %d/%d), retrying in %s: %v", workerID, job.ID, attempt, job.MaxAttempts, delay, cause)
+	if err := database.ScheduleRetry(job.ID, delay, cause.Error()); err != nil {
+		log.Printf("❌ Worker %d: failed to reschedule job #%d: %v", workerID, job.ID, err)
+		return
+	}
+	webhooks.EmitJob(webhooks.JobRetry, job.ID, map[string]any{"trigger": "backoff", "error": cause.Error()})
+}
+
+// backoff doubles WORKER_BACKOFF_BASE (default 30s) per attempt, capped at 30 minutes
+func backoff(attempt int) time.Duration {
+	base := 30 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("WORKER_BACKOFF_BASE")); err == nil && d > 0 {
+		base = d
+	}
+	delay := base << (attempt - 1)
+	if limit := 30 * time.Minute; delay > limit || delay <= 0 {
+		delay = limit
+	}
+	return delay
+}
+
+func pollInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("WORKER_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 2 * time.Second
+}
+
+// Count reads VEXORA_WORKERS, defaulting to 2
+func Count() int {
+	if n, err := strconv.Atoi(os.Getenv("VEXORA_WORKERS")); err == nil && n >= 0 {
+		return n
+	}
+	return 2
+}
+
+// splitOutput maps a generated feed onto the subject/content/tags columns
+func splitOutput(feedType, text string) (subject, content, tags string) {
+	if feedType == llm.TypeNewsletter {
+		var nl struct {
+			Subject string   `json:"subject_line"`
+			Body    string   `json:"body"`
+			Tags    []string `json:"tags"`
+		}
+		if err := json.Unmarshal([]byte(text), &nl); err == nil {
+			return nl.Subject, nl.Body, strings.Join(nl.Tags, ",")
+		}
+	}
+
+	subject, _, _ = strings.Cut(strings.TrimSpace(text), "\n")
+	if r := []rune(subject); len(r) > 80 {
+		subject = string(r[:80]) + "…"
+	}
+	return subject, text, ""
+}
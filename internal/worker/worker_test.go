@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"vexora-studio/internal/database"
+	"vexora-studio/internal/llm"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// fakeProvider answers every prompt with the same short tweet
+type fakeProvider struct{}
+
+func (fakeProvider) Name() string { return "worker-test" }
+
+func (fakeProvider) Generate(ctx context.Context, sysPrompt, userMsg, format string, opts llm.Options) (llm.Result, error) {
+	text := "Shipped the new queue today."
+	if format == "json" {
+		text = `{"text": "` + text + `"}`
+	}
+	return llm.Result{Text: text, Provider: "worker-test", Model: "fake"}, nil
+}
+
+// claimTestJob migrates a temp database and claims a twitter job in it
+func claimTestJob(t *testing.T) *database.QueueItem {
+	t.Helper()
+	t.Chdir(t.TempDir()) // Open creates ./data
+	t.Setenv("VEXORA_DB_BACKUP", "0")
+	t.Setenv("LLM_PROVIDER", "worker-test")
+	t.Setenv("LLM_FALLBACK", "")
+	t.Setenv("WORKER_BACKOFF_BASE", "1h")
+	llm.Register(fakeProvider{})
+
+	if err := database.Init(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.InsertProject(&database.Project{Slug: "acme", Name: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.EnqueueJob("acme", llm.TypeTwitter, "shipped the queue", "", 3); err != nil {
+		t.Fatal(err)
+	}
+	job, err := database.ClaimNextJob()
+	if err != nil || job == nil {
+		t.Fatalf("ClaimNextJob = %v, %v", job, err)
+	}
+	return job
+}
+
+func count(t *testing.T, table string) int {
+	t.Helper()
+	var n int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestProcess(t *testing.T) {
+	job := claimTestJob(t)
+	process(1, job)
+
+	got, err := database.GetEntry(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != database.StatusWaitingApproval || got.ContentID == 0 || got.ApprovalToken == "" {
+		t.Errorf("job = %s, content %d, token %q", got.Status, got.ContentID, got.ApprovalToken)
+	}
+	if n := count(t, "contents"); n != 1 {
+		t.Errorf("%d contents, want 1", n)
+	}
+}
+
+// A job whose result can't be saved goes back for a retry and leaves no
+// feed behind, instead of staying in PROCESSING
+func TestProcessSaveFailure(t *testing.T) {
+	job := claimTestJob(t)
+	_, err := database.DB.Exec(`CREATE TRIGGER refuse_approval_wait BEFORE UPDATE OF status ON journal_entries
+		WHEN NEW.status = 'WAITING_APPROVAL' BEGIN SELECT RAISE(ABORT, 'disk full'); END;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process(1, job)
+
+	got, err := database.GetEntry(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != database.StatusQueued || got.AttemptCount != 1 || got.ErrorMsg == "" {
+		t.Errorf("job = %s, attempt %d, error %q; want a scheduled retry", got.Status, got.AttemptCount, got.ErrorMsg)
+	}
+	for _, table := range []string{"contents", "content_revisions"} {
+		if n := count(t, table); n != 0 {
+			t.Errorf("%d orphaned rows in %s", n, table)
+		}
+	}
+}